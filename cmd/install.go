@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	conshimregistry "github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installCmdShimName   string
+	installCmdParameters map[string]string
+	installCmdUpdate     bool
+
+	installCmd = &cobra.Command{
+		Use:   "install [registry/]<shim>",
+		Short: "Installs a shim from the registries.",
+		Long: `Installs a shim from the registries that have been added to conshim. An unqualified shim name is
+looked up in the registries in priority order, as set with 'conshim registry priority'. If several
+registries outside the priority list provide the shim and none in it does, the name is ambiguous. A
+name of the form '<registry>/<shim>', where the registry is the alias or URL of a registry that has
+been added, installs the shim from that registry.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			installCmdShimName = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(installShim(installCmdShimName, installCmdParameters, installCmdUpdate))
+		},
+	}
+)
+
+func init() {
+	installCmd.Flags().StringToStringVarP(&installCmdParameters, "parameters", "p", map[string]string{}, "parameters and values for the command")
+	installCmd.Flags().BoolVarP(&installCmdUpdate, "update", "u", false, "update and overwrite an existing local shim")
+}
+
+// installShim will load the shim addressed by the name, which is either a shim name resolved across the
+// registries in priority order or a "registry/shim" name qualified by the alias or URL of a registry that
+// has been added.
+func installShim(name string, parameters map[string]string, update bool) error {
+	registryName, shimName := conshimregistry.SplitShimName(name)
+
+	if err := shim.ValidateName(shimName); err != nil {
+		return err
+	}
+
+	if registryName != "" {
+		entry, err := config.FindRegistry(registryName)
+
+		if err != nil {
+			return fmt.Errorf("%v; add it with 'conshim registry add' first", err)
+		}
+
+		m, err := config.ReadFreshManifest(entry)
+
+		if err != nil {
+			return err
+		}
+
+		if _, ok := m.GetShim(shimName); !ok {
+			return fmt.Errorf("no shim '%s' was found in registry %s", shimName, registryName)
+		}
+
+		if err := config.LoadShim(m, shimName, parameters, update); err != nil {
+			return err
+		}
+
+		fmt.Printf("Installed shim '%s' from registry '%s'.\n", shimName, registryName)
+
+		return nil
+	}
+
+	provider, others, err := config.ResolveShim(shimName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(others) > 0 {
+		fmt.Printf("Shim '%s' is also provided by %s, use '<registry>/%s' to install it from there instead.\n", shimName, strings.Join(others, ", "), shimName)
+	}
+
+	if err := config.LoadShim(provider.Manifest, shimName, parameters, update); err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed shim '%s' from registry '%s'.\n", shimName, provider.Registry)
+
+	return nil
+}
@@ -3,6 +3,7 @@ package manifest
 import (
 	"fmt"
 
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/meowfaceman/conshim/pkg/shim"
 	"github.com/spf13/cobra"
 )
@@ -23,9 +24,17 @@ var (
 					Description: shimDescription,
 					Parameters:  shimParameters,
 					Command:     shimCommand,
+					Platforms:   shimPlatforms,
+					Variants:    shimVariants,
+					Tags:        shimTags,
+					Categories:  shimCategories,
+					Image:       shimImage,
+
+					MinConshimVersion: shimMinConshim,
 				}
 
 				cobra.CheckErr(m.AddShim(shimName, newShim))
+				recordChange(m, manifest.ActionAdd, shimName)
 
 				fmt.Printf("Added shim '%s' to manifest %s.\n", shimName, m.Source)
 			}()
@@ -39,4 +48,6 @@ func init() {
 	bindCommonManifestFlags(addShimCmd)
 	bindShimFlags(addShimCmd)
 	bindShimModificationFlags(addShimCmd)
+	bindVersionFlags(addShimCmd)
+	bindEncodingFlags(addShimCmd)
 }
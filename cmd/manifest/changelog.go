@@ -0,0 +1,27 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogCmd = &cobra.Command{
+		Use:   "changelog",
+		Short: "Prints the changelog of the manifest.",
+		Long:  "Prints the changelog of the manifest, optionally limited to a single shim.",
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m, closeFunc := readManifestFile()
+			defer closeFunc()
+
+			fmt.Print(m.ChangelogToString(shimName))
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(changelogCmd)
+	bindShimFlags(changelogCmd)
+}
@@ -3,19 +3,35 @@ package manifest
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/semver"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/meowfaceman/conshim/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 var (
 	manifestFileName string
 
+	versionBump     string
+	manifestVersion string
+	changeMessage   string
+
+	manifestEncoding string
+
 	shimName        string
 	shimVersion     string
 	shimDescription string
 	shimParameters  []string
 	shimCommand     string
+	shimPlatforms   []string
+	shimVariants    map[string]string
+	shimTags        []string
+	shimCategories  []string
+	shimImage       string
+	shimMinConshim  string
 )
 
 // bindCommonManifestFlags will bind flags that are common to all manifest commands.
@@ -34,6 +50,106 @@ func bindShimModificationFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&shimDescription, "shim-description", "d", "", "the description of the shim")
 	cmd.Flags().StringSliceVarP(&shimParameters, "shim-parameters", "p", []string{}, "the parameters that can be adjusted for the shim")
 	cmd.Flags().StringVarP(&shimCommand, "shim-command", "c", "", "the command executed by the shim")
+	cmd.Flags().StringSliceVar(&shimPlatforms, "shim-platforms", []string{}, "the platforms (os/arch or os) the shim can run on")
+	cmd.Flags().StringToStringVar(&shimVariants, "shim-variants", map[string]string{}, "platform specific commands for the shim, in the form os/arch=command")
+	cmd.Flags().StringSliceVar(&shimTags, "shim-tags", []string{}, "keywords used to find the shim")
+	cmd.Flags().StringSliceVar(&shimCategories, "shim-categories", []string{}, "categories the shim belongs to")
+	cmd.Flags().StringVar(&shimImage, "shim-image", "", "the container image used by the shim, substituted for {{image}} in the command")
+	cmd.Flags().StringVar(&shimMinConshim, "shim-min-conshim-version", "", "the minimum version of conshim needed to use the shim")
+}
+
+// mergeShimFlags will apply the shim modification flags that were passed on the command line to the shim,
+// leaving the fields of flags that weren't passed untouched.
+func mergeShimFlags(cmd *cobra.Command, s shim.Shim) shim.Shim {
+	flags := cmd.Flags()
+
+	if flags.Changed("shim-version") {
+		s.Version = shimVersion
+	}
+
+	if flags.Changed("shim-description") {
+		s.Description = shimDescription
+	}
+
+	if flags.Changed("shim-parameters") {
+		s.Parameters = shimParameters
+	}
+
+	if flags.Changed("shim-command") {
+		s.Command = shimCommand
+	}
+
+	if flags.Changed("shim-platforms") {
+		s.Platforms = shimPlatforms
+	}
+
+	if flags.Changed("shim-variants") {
+		s.Variants = shimVariants
+	}
+
+	if flags.Changed("shim-tags") {
+		s.Tags = shimTags
+	}
+
+	if flags.Changed("shim-categories") {
+		s.Categories = shimCategories
+	}
+
+	if flags.Changed("shim-min-conshim-version") {
+		s.MinConshimVersion = shimMinConshim
+	}
+
+	// A digest pinned for a different image is meaningless, so changing the image unpins it.
+	if flags.Changed("shim-image") && shimImage != s.Image {
+		s.Image = shimImage
+		s.Digest = ""
+	}
+
+	return s
+}
+
+// bindVersionFlags will bind flags that are common to commands that change the manifest.
+func bindVersionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&versionBump, "bump", string(semver.BumpPatch), "the part of the manifest version to bump: major, minor, patch or none")
+	cmd.Flags().StringVar(&manifestVersion, "set-version", "", "explicitly set the manifest version instead of bumping it")
+	cmd.Flags().StringVar(&changeMessage, "message", "", "a message describing the change for the changelog")
+}
+
+// bindEncodingFlags will bind flags that are common to commands that write the manifest.
+func bindEncodingFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&manifestEncoding, "encoding", "", fmt.Sprintf("re-encode the manifest as one of %s", encodingNames()))
+}
+
+// encodingNames will return a comma separated list of manifest encodings.
+func encodingNames() string {
+	names := []string{}
+	for _, encoding := range manifest.Encodings {
+		names = append(names, string(encoding))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// recordChange will update the manifest version and record the change to the shims in the changelog.
+func recordChange(m *manifest.Manifest, action string, shimNames ...string) {
+	changes := map[string]string{}
+	for _, shimName := range shimNames {
+		changes[shimName] = action
+	}
+
+	recordChanges(m, changes)
+}
+
+// recordChanges will update the manifest version once and record the changes, keyed by shim name, in
+// the changelog.
+func recordChanges(m *manifest.Manifest, changes map[string]string) {
+	if manifestVersion != "" {
+		cobra.CheckErr(m.SetVersion(manifestVersion))
+	} else {
+		cobra.CheckErr(m.BumpVersion(semver.Bump(versionBump)))
+	}
+
+	m.AddChangelogEntries(changes, changeMessage)
 }
 
 // readManifestFile will read the configured manifest file and return it along with a close function.
@@ -50,16 +166,17 @@ func readManifestFile() (*manifest.Manifest, func()) {
 
 }
 
-// writeManifestFile will write the configured manifest file. If it exists already, it will be re-written.
+// writeManifestFile will write the configured manifest file. If it exists already, it will be replaced
+// atomically.
 func writeManifestFile(m *manifest.Manifest) {
-	manifestFile, err := os.OpenFile(manifestFileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	cobra.CheckErr(err)
+	if manifestEncoding != "" {
+		encoding, err := manifest.ParseEncoding(manifestEncoding)
+		cobra.CheckErr(err)
 
-	defer func() {
-		cobra.CheckErr(manifestFile.Close())
-	}()
+		m.Encoding = encoding
+	}
 
-	cobra.CheckErr(m.WriteManifest(manifestFile))
+	cobra.CheckErr(utils.WriteFileAtomic(manifestFileName, 0644, m.WriteManifest))
 
 	fmt.Printf("Manifest written to '%s'\n", manifestFileName)
 }
@@ -5,11 +5,15 @@ import (
 	"os"
 
 	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/semver"
 	"github.com/spf13/cobra"
 )
 
 var (
 	createCmdSourceName string
+	createCmdVersion    string
+	createCmdEncoding   string
+	createCmdMinConshim string
 
 	createCmd = &cobra.Command{
 		Use:   "create <source-name>",
@@ -33,6 +37,21 @@ var (
 			}
 
 			m := manifest.CreateManifest(createCmdSourceName)
+
+			version, err := semver.Parse(createCmdVersion)
+			cobra.CheckErr(err)
+			m.Version = version.String()
+
+			m.Encoding, err = manifest.ParseEncoding(createCmdEncoding)
+			cobra.CheckErr(err)
+
+			if createCmdMinConshim != "" {
+				minConshim, err := semver.Parse(createCmdMinConshim)
+				cobra.CheckErr(err)
+
+				m.MinConshimVersion = minConshim.String()
+			}
+
 			writeManifestFile(m)
 		},
 	}
@@ -40,4 +59,8 @@ var (
 
 func init() {
 	bindCommonManifestFlags(createCmd)
+
+	createCmd.Flags().StringVar(&createCmdVersion, "set-version", manifest.InitialVersion, "the initial version of the manifest")
+	createCmd.Flags().StringVar(&createCmdMinConshim, "min-conshim-version", "", "the minimum version of conshim needed to use the manifest")
+	createCmd.Flags().StringVar(&createCmdEncoding, "encoding", string(manifest.DefaultEncoding), fmt.Sprintf("the encoding of the manifest, one of %s; only %s can be read by older versions of conshim", encodingNames(), manifest.EncodingLegacyBrotli))
 }
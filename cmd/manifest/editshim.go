@@ -0,0 +1,96 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/editor"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	editShimCmdShimName string
+
+	editShimCmd = &cobra.Command{
+		Use:   "edit-shim <name>",
+		Short: "Edits a shim in the manifest with $EDITOR.",
+		Long: `Opens a shim from the manifest as YAML in $VISUAL or $EDITOR. When the editor exits, the shim
+is validated and the manifest is written. If the shim is invalid, the editor is re-opened with
+the errors. Exiting without changes, or with an empty file, leaves the manifest untouched.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			editShimCmdShimName = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			existingShim, ok := m.Shims[editShimCmdShimName]
+			if !ok {
+				cobra.CheckErr(fmt.Sprintf("shim '%s' does not exist in the manifest", editShimCmdShimName))
+			}
+
+			initial, err := yaml.Marshal(existingShim)
+			cobra.CheckErr(err)
+
+			edited, err := editor.New().Edit(initial, "*.yaml", func(contents []byte) error {
+				_, parseErr := parseShimYAML(contents)
+				return parseErr
+			})
+
+			if errors.Is(err, editor.ErrUnchanged) || errors.Is(err, editor.ErrEmpty) {
+				fmt.Printf("Shim '%s' was not changed: %v.\n", editShimCmdShimName, err)
+				return
+			}
+
+			cobra.CheckErr(err)
+
+			editedShim, err := parseShimYAML(edited)
+			cobra.CheckErr(err)
+
+			cobra.CheckErr(m.UpdateShim(editShimCmdShimName, editedShim))
+			recordChange(m, manifest.ActionUpdate, editShimCmdShimName)
+
+			writeManifestFile(m)
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(editShimCmd)
+	bindVersionFlags(editShimCmd)
+	bindEncodingFlags(editShimCmd)
+}
+
+// parseShimYAML will strictly parse and validate a shim from YAML.
+func parseShimYAML(contents []byte) (shim.Shim, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+
+	s := shim.Shim{}
+	if err := decoder.Decode(&s); err != nil {
+		return shim.Shim{}, errors.Wrap(err, "invalid YAML")
+	}
+
+	if s.Name != "" || s.Source != "" {
+		return shim.Shim{}, errors.New("name and source are set by the manifest and can't be edited")
+	}
+
+	if err := s.Validate(); err != nil {
+		return shim.Shim{}, err
+	}
+
+	return s, nil
+}
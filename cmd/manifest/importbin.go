@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importBinCmdDescriptions map[string]string
+	importBinCmdUpdate       bool
+
+	importBinCmd = &cobra.Command{
+		Use:   "import-bin [shim...]",
+		Short: "Imports locally installed shims into the manifest.",
+		Long: `Imports shims from the local conshim bin directory into the manifest. If no shims are given,
+every shim in the bin directory is imported. Parameters are detected from {{parameter}}
+placeholders in the shim commands. Shims that can't be parsed are skipped and reported.`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			shimNames := args
+			if len(shimNames) == 0 {
+				var err error
+				shimNames, err = config.Directory().ListBinFiles()
+				cobra.CheckErr(err)
+			}
+
+			report := &manifest.ImportReport{}
+			for _, shimName := range shimNames {
+				localShim, err := config.ReadShim(shimName)
+
+				if err != nil {
+					report.Skip(shimName, err.Error())
+					continue
+				}
+
+				m.ImportShim(shimName, manifest.FromLocalShim(localShim, importBinCmdDescriptions[shimName]), importBinCmdUpdate, report)
+			}
+
+			fmt.Print(report)
+
+			if changes := report.Changes(); len(changes) > 0 {
+				recordChanges(m, changes)
+				writeManifestFile(m)
+			}
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(importBinCmd)
+	bindVersionFlags(importBinCmd)
+	bindEncodingFlags(importBinCmd)
+
+	importBinCmd.Flags().StringToStringVar(&importBinCmdDescriptions, "descriptions", map[string]string{}, "descriptions for the imported shims, in the form shim=description")
+	importBinCmd.Flags().BoolVarP(&importBinCmdUpdate, "update", "u", false, "overwrite shims that already exist in the manifest")
+}
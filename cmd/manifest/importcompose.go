@@ -0,0 +1,133 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/compose"
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importComposeCmdFile     string
+	importComposeCmdServices []string
+	importComposeCmdUpdate   bool
+	importComposeCmdYes      bool
+	importComposeCmdDryRun   bool
+	importComposeCmdAbsolute bool
+
+	importComposeCmd = &cobra.Command{
+		Use:   "import-compose <file>",
+		Short: "Imports docker-compose services into the manifest.",
+		Long: `Creates one manifest shim for each selected service in a docker-compose file. The image,
+volumes, environment, working_dir, entrypoint and command of each service are mapped onto a
+container run command. Relative volumes are relative to the directory the shim is run from, unless
+--absolute-volumes is given. The generated shims are previewed before the manifest is written.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			importComposeCmdFile = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := compose.ParseFile(importComposeCmdFile)
+			cobra.CheckErr(err)
+
+			if importComposeCmdAbsolute {
+				file.Dir, err = filepath.Abs(filepath.Dir(importComposeCmdFile))
+				cobra.CheckErr(err)
+			}
+
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			serviceNames := importComposeCmdServices
+			if len(serviceNames) == 0 {
+				serviceNames = file.ServiceNames()
+			}
+
+			runtime := config.ContainerRuntime()
+			report := &manifest.ImportReport{}
+			preview := []shim.Shim{}
+			for _, serviceName := range serviceNames {
+				serviceShim, err := file.Shim(serviceName, runtime)
+
+				if err != nil {
+					report.Skip(serviceName, err.Error())
+					continue
+				}
+
+				m.ImportShim(serviceName, serviceShim, importComposeCmdUpdate, report)
+
+				serviceShim.Name = serviceName
+				preview = append(preview, serviceShim)
+			}
+
+			// Only preview the shims that will be written, not the ones that were skipped.
+			changes := report.Changes()
+			changedShims := []shim.Shim{}
+			for _, previewShim := range preview {
+				if _, ok := changes[previewShim.Name]; ok {
+					changedShims = append(changedShims, previewShim)
+				}
+			}
+
+			if len(changedShims) > 0 {
+				fmt.Print(shim.ShimsListToString(changedShims))
+				fmt.Println("-------")
+			}
+
+			fmt.Print(report)
+
+			if len(changes) == 0 || importComposeCmdDryRun {
+				return
+			}
+
+			if !importComposeCmdYes && !confirm(fmt.Sprintf("Write %d shim(s) to '%s'?", len(changes), manifestFileName)) {
+				fmt.Println("Manifest was not written.")
+				return
+			}
+
+			recordChanges(m, changes)
+			writeManifestFile(m)
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(importComposeCmd)
+	bindVersionFlags(importComposeCmd)
+	bindEncodingFlags(importComposeCmd)
+
+	importComposeCmd.Flags().StringSliceVarP(&importComposeCmdServices, "services", "s", []string{}, "the services to import, defaults to all services")
+	importComposeCmd.Flags().BoolVarP(&importComposeCmdUpdate, "update", "u", false, "overwrite shims that already exist in the manifest")
+	importComposeCmd.Flags().BoolVarP(&importComposeCmdYes, "yes", "y", false, "write the manifest without asking for confirmation")
+	importComposeCmd.Flags().BoolVar(&importComposeCmdDryRun, "dry-run", false, "preview the generated shims without writing the manifest")
+	importComposeCmd.Flags().BoolVar(&importComposeCmdAbsolute, "absolute-volumes", false, "resolve relative volumes against the directory of the compose file, which only exists on this machine")
+}
+
+// confirm will ask the user a yes or no question on the terminal, defaulting to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,84 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/container"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importImageCmdImage string
+	importImageCmdName  string
+	importImageCmdPull  bool
+
+	importImageCmd = &cobra.Command{
+		Use:   "import-image <image>",
+		Short: "Imports a shim from an image's labels into the manifest.",
+		Long: `Reads the io.conshim.* labels of an image through the configured container runtime and
+creates or updates the corresponding manifest shim. An existing shim keeps every field the labels don't
+set, such as its version and platforms. The supported labels are:
+
+  io.conshim.name         the name of the shim, defaults to the image repository's base name
+  io.conshim.description  the description of the shim
+  io.conshim.parameters   comma separated shim parameters, detected from the command if unset
+  io.conshim.command      the full shim command, {{image}} is replaced with the image
+  io.conshim.entrypoint   the entrypoint used when generating the command
+  io.conshim.env          comma separated environment variables passed through to the container
+  io.conshim.tags         comma separated shim tags
+  io.conshim.categories   comma separated shim categories`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			importImageCmdImage = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			runtime := config.ContainerRuntime()
+
+			if importImageCmdPull {
+				cobra.CheckErr(runtime.Pull(importImageCmdImage))
+			}
+
+			labels, err := runtime.Labels(importImageCmdImage)
+			cobra.CheckErr(err)
+
+			name, err := container.ShimNameFromLabels(importImageCmdImage, labels)
+			cobra.CheckErr(err)
+
+			if importImageCmdName != "" {
+				name = importImageCmdName
+			}
+
+			// Refreshing an existing shim only changes what the labels set, keeping its other fields.
+			imageShim := runtime.MergeLabels(importImageCmdImage, labels, m.Shims[name])
+
+			report := &manifest.ImportReport{}
+			m.ImportShim(name, imageShim, true, report)
+			fmt.Print(report)
+
+			recordChanges(m, report.Changes())
+			writeManifestFile(m)
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(importImageCmd)
+	bindVersionFlags(importImageCmd)
+	bindEncodingFlags(importImageCmd)
+
+	importImageCmd.Flags().StringVarP(&importImageCmdName, "shim-name", "n", "", "override the name of the shim from the image labels")
+	importImageCmd.Flags().BoolVar(&importImageCmdPull, "pull", false, "pull the image before reading its labels")
+}
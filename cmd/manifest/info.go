@@ -17,6 +17,7 @@ var (
 			defer closeFunc()
 
 			fmt.Printf("Source: %s\n", m.Source)
+			fmt.Printf("Version: %s\n", m.Version)
 			fmt.Printf("Number of shims: %d\n", len(m.Shims))
 		},
 	}
@@ -3,10 +3,13 @@ package manifest
 import (
 	"fmt"
 
+	"github.com/meowfaceman/conshim/pkg/shim"
 	"github.com/spf13/cobra"
 )
 
 var (
+	listShimsCmdCompatible bool
+
 	listShimsCmd = &cobra.Command{
 		Use:   "list-shims",
 		Short: "List shims from the manifest.",
@@ -16,6 +19,10 @@ var (
 			m, closeFunc := readManifestFile()
 			defer closeFunc()
 
+			if listShimsCmdCompatible {
+				m = m.FilterShims(shim.Shim.IsCompatibleWithHost)
+			}
+
 			fmt.Print(m.ShimsToString())
 		},
 	}
@@ -23,4 +30,6 @@ var (
 
 func init() {
 	bindCommonManifestFlags(listShimsCmd)
+
+	listShimsCmd.Flags().BoolVar(&listShimsCmdCompatible, "compatible", false, "only list shims compatible with this host")
 }
@@ -34,7 +34,13 @@ var (
 			defer closeFunc()
 
 			if manifestShim, ok := m.GetShim(loadShimCmdShimName); ok {
-				renderedShim, err := manifestShim.RenderShim(loadShimCmdParameters)
+				cobra.CheckErr(m.CheckShimClientVersion(loadShimCmdShimName))
+
+				// Refuse shims that can't run on this host, or pick the variant that can.
+				hostShim, err := manifestShim.ForHost()
+				cobra.CheckErr(err)
+
+				renderedShim, err := hostShim.RenderShim(loadShimCmdParameters)
 				cobra.CheckErr(err)
 
 				if loadShimCmdUpdate {
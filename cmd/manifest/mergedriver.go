@@ -0,0 +1,56 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeDriverCmd = &cobra.Command{
+		Use:   "merge-driver <base> <ours> <theirs>",
+		Short: "Merges manifests as a git merge driver.",
+		Long: `Does a three-way merge of the manifests in the base, ours and theirs files and writes the result
+to the ours file. Shims changed on only one side are merged, and shims changed differently on both
+sides are reported as conflicts, keeping our side. The command fails if there were any conflicts.
+To set it up in a registry repository:
+
+  git config merge.conshim.driver "conshim manifest merge-driver %O %A %B"
+  echo "manifest.br merge=conshim" >> .gitattributes`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 3 {
+				return fmt.Errorf("expected 3 arguments, got %d", numArgs)
+			}
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			base := readManifestPath(args[0])
+			ours := readManifestPath(args[1])
+			theirs := readManifestPath(args[2])
+
+			merged, conflicts := manifest.Merge(base, ours, theirs)
+
+			manifestFile, err := os.OpenFile(args[1], os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			cobra.CheckErr(err)
+
+			cobra.CheckErr(merged.WriteManifest(manifestFile))
+			cobra.CheckErr(manifestFile.Close())
+
+			if len(conflicts) == 0 {
+				return
+			}
+
+			for _, conflict := range conflicts {
+				fmt.Fprintf(os.Stderr, "CONFLICT (conshim manifest): %s\n", conflict)
+			}
+
+			os.Exit(1)
+		},
+	}
+)
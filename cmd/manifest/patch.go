@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	patchCmdFile   string
+	patchCmdType   string
+	patchCmdDryRun bool
+
+	patchCmd = &cobra.Command{
+		Use:   "patch <patch-file>",
+		Short: "Applies a JSON Patch or merge patch to the manifest.",
+		Long: `Applies an RFC 6902 JSON Patch or an RFC 7386 JSON merge patch to the manifest as it's serialized
+in JSON, such as:
+
+  [{"op": "replace", "path": "/shims/jq/image", "value": "jq:1.7"}]
+  {"shims": {"jq": {"image": "jq:1.7"}}}
+
+The patch type is detected from the document unless --type is given. Use - to read the patch from
+stdin. The patched manifest is validated against the manifest schema and the changed shims are
+validated before the manifest is written. Changed shims are recorded in the changelog, and the
+manifest version is bumped unless the patch changed it.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			patchCmdFile = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			var patch []byte
+			var err error
+
+			if patchCmdFile == "-" {
+				patch, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				patch, err = ioutil.ReadFile(patchCmdFile)
+			}
+
+			cobra.CheckErr(err)
+
+			var patchType manifest.PatchType
+			if patchCmdType != "" {
+				patchType, err = manifest.ParsePatchType(patchCmdType)
+			} else {
+				patchType, err = manifest.DetectPatchType(patch)
+			}
+
+			cobra.CheckErr(err)
+
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			patched, err := m.Patch(patchType, patch)
+			cobra.CheckErr(err)
+
+			changes := manifest.Diff(m, patched)
+
+			shimNames := []string{}
+			for shimName := range changes {
+				shimNames = append(shimNames, shimName)
+			}
+
+			sort.Strings(shimNames)
+
+			for _, shimName := range shimNames {
+				fmt.Printf("%s shim '%s'\n", changes[shimName], shimName)
+			}
+
+			if len(changes) == 0 {
+				fmt.Println("No shims were changed.")
+			}
+
+			if patchCmdDryRun {
+				return
+			}
+
+			if patched.Version != m.Version {
+				patched.AddChangelogEntries(changes, changeMessage)
+			} else if len(changes) > 0 {
+				recordChanges(patched, changes)
+			}
+
+			writeManifestFile(patched)
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(patchCmd)
+	bindVersionFlags(patchCmd)
+	bindEncodingFlags(patchCmd)
+
+	patchCmd.Flags().StringVar(&patchCmdType, "type", "", fmt.Sprintf("the patch type, %s or %s, detected from the patch by default", manifest.PatchTypeJSONPatch, manifest.PatchTypeMergePatch))
+	patchCmd.Flags().BoolVar(&patchCmdDryRun, "dry-run", false, "print the changes without writing the manifest")
+}
@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pinDigestsCmdCheck bool
+	pinDigestsCmdPull  bool
+
+	pinDigestsCmd = &cobra.Command{
+		Use:   "pin-digests",
+		Short: "Pins the images of the manifest's shims to digests.",
+		Long: `Resolves every image referenced by the manifest's shims to a digest using the configured
+container runtime and records the digests in the manifest. Rendered shims will then run
+image@sha256:... instead of a mutable tag. With --check, the manifest is left untouched and
+any drift between the tags and the pinned digests is reported. Shims without an image can't be
+pinned and are warned about, but don't count as drift.`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			runtime := config.ContainerRuntime()
+			resolve := func(image string) (string, error) {
+				if pinDigestsCmdPull {
+					if err := runtime.Pull(image); err != nil {
+						return "", err
+					}
+				}
+
+				return runtime.ResolveDigest(image)
+			}
+
+			if pinDigestsCmdCheck {
+				drifts, unpinnable, err := m.CheckDigests(resolve)
+				cobra.CheckErr(err)
+
+				warnUnpinnable(unpinnable)
+
+				for _, drift := range drifts {
+					fmt.Println(drift)
+				}
+
+				if len(drifts) > 0 {
+					cobra.CheckErr(fmt.Errorf("%d shim(s) have drifted from their pinned digests", len(drifts)))
+				}
+
+				fmt.Println("All shim digests are up to date.")
+
+				return
+			}
+
+			pinned, unpinnable, err := m.PinDigests(resolve)
+			cobra.CheckErr(err)
+
+			warnUnpinnable(unpinnable)
+
+			if len(pinned) == 0 {
+				fmt.Println("All shim digests are up to date.")
+				return
+			}
+
+			for _, shimName := range pinned {
+				fmt.Printf("Pinned shim '%s' to %s.\n", shimName, m.Shims[shimName].Digest)
+			}
+
+			recordChange(m, manifest.ActionPin, pinned...)
+			writeManifestFile(m)
+		},
+	}
+)
+
+// warnUnpinnable will warn about the shims that have no image and so can't be pinned.
+func warnUnpinnable(shimNames []string) {
+	for _, shimName := range shimNames {
+		fmt.Printf("Warning: shim '%s' has no image, so it can't be pinned.\n", shimName)
+	}
+}
+
+func init() {
+	bindCommonManifestFlags(pinDigestsCmd)
+	bindVersionFlags(pinDigestsCmd)
+	bindEncodingFlags(pinDigestsCmd)
+
+	pinDigestsCmd.Flags().BoolVar(&pinDigestsCmdCheck, "check", false, "report drift between image tags and pinned digests without changing the manifest")
+	pinDigestsCmd.Flags().BoolVar(&pinDigestsCmdPull, "pull", false, "pull each image before resolving its digest")
+}
@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,7 @@ var (
 			defer closeFunc()
 
 			cobra.CheckErr(m.RemoveShim(shimName))
+			recordChange(m, manifest.ActionRemove, shimName)
 
 			writeManifestFile(m)
 		},
@@ -24,4 +26,6 @@ var (
 func init() {
 	bindCommonManifestFlags(removeShimCmd)
 	bindShimFlags(removeShimCmd)
+	bindVersionFlags(removeShimCmd)
+	bindEncodingFlags(removeShimCmd)
 }
@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/spf13/cobra"
 )
 
@@ -13,15 +14,29 @@ var (
 )
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&manifest.Strict, "strict", manifest.Strict, "validate manifests against the manifest JSON Schema when reading them")
+
 	rootCmd.AddCommand(addShimCmd)
+	rootCmd.AddCommand(changelogCmd)
 	rootCmd.AddCommand(createCmd)
+	rootCmd.AddCommand(editShimCmd)
 	rootCmd.AddCommand(getShimCmd)
+	rootCmd.AddCommand(importBinCmd)
+	rootCmd.AddCommand(importComposeCmd)
+	rootCmd.AddCommand(importImageCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(listShimsCmd)
 	rootCmd.AddCommand(loadShimCmd)
+	rootCmd.AddCommand(mergeDriverCmd)
+	rootCmd.AddCommand(patchCmd)
+	rootCmd.AddCommand(pinDigestsCmd)
 	rootCmd.AddCommand(removeShimCmd)
 	rootCmd.AddCommand(renderShimCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(textconvCmd)
 	rootCmd.AddCommand(updateShimCmd)
+	rootCmd.AddCommand(vendorCmd)
+	rootCmd.AddCommand(vendorStatusCmd)
 }
 
 func Root() *cobra.Command {
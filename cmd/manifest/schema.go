@@ -0,0 +1,25 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Prints the manifest JSON Schema.",
+		Long: `Prints the JSON Schema of the manifest format. The shim format is defined under $defs/shim. Manifests
+are validated against the schema when reading them with --strict or CONSHIM_MANIFEST_STRICT=true, such
+as with 'conshim manifest info --strict' in a registry's CI.`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			schema, err := manifest.SchemaJSON()
+			cobra.CheckErr(err)
+
+			fmt.Print(string(schema))
+		},
+	}
+)
@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	textconvCmdFile string
+
+	textconvCmd = &cobra.Command{
+		Use:   "textconv <file>",
+		Short: "Prints a manifest as canonical YAML.",
+		Long: `Prints the manifest in the file as YAML with its shims sorted by name, so that the same manifest
+always prints the same way. This is meant to be used as a git textconv driver so that git diffs of
+manifests are readable. To set it up in a registry repository:
+
+  git config diff.conshim.textconv "conshim manifest textconv"
+  echo "manifest.br diff=conshim" >> .gitattributes`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			textconvCmdFile = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m := readManifestPath(textconvCmdFile)
+
+			rendered, err := m.ToYAML()
+			cobra.CheckErr(err)
+
+			fmt.Printf("# encoding: %s\n", m.Encoding)
+			fmt.Print(string(rendered))
+		},
+	}
+)
+
+// readManifestPath will read the manifest at the given path. An empty file is read as an empty manifest,
+// which is what git passes to drivers for files that don't exist on one side.
+func readManifestPath(path string) *manifest.Manifest {
+	manifestFile, err := os.Open(path)
+	cobra.CheckErr(err)
+
+	defer func() {
+		cobra.CheckErr(manifestFile.Close())
+	}()
+
+	info, err := manifestFile.Stat()
+	cobra.CheckErr(err)
+
+	if info.Size() == 0 {
+		m := manifest.CreateManifest("")
+		m.Version = ""
+
+		return m
+	}
+
+	m, err := manifest.ReadManifest(manifestFile)
+	cobra.CheckErr(err)
+
+	return m
+}
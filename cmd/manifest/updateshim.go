@@ -1,7 +1,7 @@
 package manifest
 
 import (
-	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/spf13/cobra"
 )
 
@@ -9,20 +9,14 @@ var (
 	updateShimCmd = &cobra.Command{
 		Use:   "update-shim",
 		Short: "Updates a shim in the manifest.",
-		Long:  "Updates a shim entry in the manifest.",
+		Long:  "Updates a shim entry in the manifest. Only the fields for the flags that are passed are changed.",
 
 		Run: func(cmd *cobra.Command, args []string) {
 			m, closeFunc := readManifestFile()
 			defer closeFunc()
 
-			newShim := shim.Shim{
-				Version:     shimVersion,
-				Description: shimDescription,
-				Parameters:  shimParameters,
-				Command:     shimCommand,
-			}
-
-			cobra.CheckErr(m.UpdateShim(shimName, newShim))
+			cobra.CheckErr(m.UpdateShim(shimName, mergeShimFlags(cmd, m.Shims[shimName])))
+			recordChange(m, manifest.ActionUpdate, shimName)
 
 			writeManifestFile(m)
 		},
@@ -33,4 +27,6 @@ func init() {
 	bindCommonManifestFlags(updateShimCmd)
 	bindShimFlags(updateShimCmd)
 	bindShimModificationFlags(updateShimCmd)
+	bindVersionFlags(updateShimCmd)
+	bindEncodingFlags(updateShimCmd)
 }
@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	vendorCmdRegistryName string
+	vendorCmdShimName     string
+	vendorCmdLocalName    string
+	vendorCmdUpdate       bool
+	vendorCmdFetch        bool
+
+	vendorCmd = &cobra.Command{
+		Use:   "vendor <upstream-registry> <shim>",
+		Short: "Copies a shim from an upstream registry into the manifest.",
+		Long: `Copies a shim from an upstream registry into the manifest, recording the upstream registry, the
+shim's upstream version and a checksum of its contents so that 'manifest vendor-status' can tell
+when it falls behind or is modified locally. The upstream registry is looked up by alias or URL among
+the added registries, and its locally added copy is used unless --fetch is given. A registry that
+hasn't been added is fetched from its URL.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 2 {
+				return fmt.Errorf("expected 2 arguments, got %d", numArgs)
+			}
+
+			vendorCmdRegistryName = args[0]
+			vendorCmdShimName = args[1]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			upstream, err := readUpstream(vendorCmdRegistryName, vendorCmdFetch)
+			cobra.CheckErr(err)
+
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			localName := vendorCmdLocalName
+			if localName == "" {
+				localName = vendorCmdShimName
+			}
+
+			action, err := m.VendorShim(upstream, vendorCmdShimName, localName, vendorCmdUpdate)
+			cobra.CheckErr(err)
+
+			recordChange(m, action, localName)
+			writeManifestFile(m)
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(vendorCmd)
+	bindVersionFlags(vendorCmd)
+	bindEncodingFlags(vendorCmd)
+
+	vendorCmd.Flags().StringVar(&vendorCmdLocalName, "as", "", "the name of the shim in the manifest, defaults to the upstream name")
+	vendorCmd.Flags().BoolVarP(&vendorCmdUpdate, "update", "u", false, "re-vendor a shim that already exists in the manifest")
+	vendorCmd.Flags().BoolVar(&vendorCmdFetch, "fetch", false, "fetch the upstream registry even if it has been added locally")
+}
+
+// readUpstream will read the manifest of an upstream registry, resolving the name to an added registry
+// first. The locally added copy is used unless fetch is true, in which case the registry is refreshed with
+// its own options. The name is only fetched as a bare URL if no registry has been added for it. The
+// manifest's source is set to the location it was read from rather than the source it declares, so that
+// vendored shims record where their upstream can be found again.
+func readUpstream(registryName string, fetch bool) (*manifest.Manifest, error) {
+	entry, err := config.FindRegistry(registryName)
+
+	if err != nil {
+		zap.S().Debugf("registry '%s' hasn't been added locally, fetching it: %v", registryName, err)
+
+		r, err := registry.GetRegistry(registryName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m := r.GetManifest()
+		m.Source = registryName
+
+		return m, nil
+	}
+
+	var m *manifest.Manifest
+	if fetch {
+		m, err = config.RefreshRegistry(entry)
+	} else {
+		m, err = config.ReadRegistryManifest(entry)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.Source = entry.Location()
+
+	return m, nil
+}
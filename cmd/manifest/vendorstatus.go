@@ -0,0 +1,55 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vendorStatusCmdFetch bool
+	vendorStatusCmdCheck bool
+
+	vendorStatusCmd = &cobra.Command{
+		Use:   "vendor-status",
+		Short: "Compares vendored shims against their upstream registries.",
+		Long: `Compares every vendored shim in the manifest against the current manifest of its upstream registry,
+and lists the shims that are behind upstream, modified locally, or removed upstream. Upstreams are
+looked up among the added registries, whose locally added copies are used unless --fetch is given.`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			m, closeFunc := readManifestFile()
+			closeFunc()
+
+			statuses := m.VendorStatuses(func(source string) (*manifest.Manifest, error) {
+				return readUpstream(source, vendorStatusCmdFetch)
+			})
+
+			if len(statuses) == 0 {
+				fmt.Println("No shims are vendored.")
+				return
+			}
+
+			outdated := 0
+			for _, status := range statuses {
+				fmt.Println(status)
+
+				if status.State != manifest.VendorUpToDate {
+					outdated++
+				}
+			}
+
+			if vendorStatusCmdCheck && outdated > 0 {
+				cobra.CheckErr(fmt.Sprintf("%d vendored shim(s) are not up to date", outdated))
+			}
+		},
+	}
+)
+
+func init() {
+	bindCommonManifestFlags(vendorStatusCmd)
+
+	vendorStatusCmd.Flags().BoolVar(&vendorStatusCmdFetch, "fetch", false, "fetch the upstream registries even if they have been added locally")
+	vendorStatusCmd.Flags().BoolVar(&vendorStatusCmdCheck, "check", false, "exit with an error if any vendored shim isn't up to date")
+}
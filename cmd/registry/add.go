@@ -4,16 +4,36 @@ import (
 	"fmt"
 
 	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/meowfaceman/conshim/pkg/registry"
 	"github.com/spf13/cobra"
 )
 
 var (
 	addRegistryName string
-	addCmd          = &cobra.Command{
-		Use:   "add <registry-name>",
+	addCmdRef       string
+	addCmdPath      string
+	addCmdAlias     string
+
+	addCmdAllowSourceMismatch bool
+
+	addCmd = &cobra.Command{
+		Use:   "add <registry-url>",
 		Short: "Adds a registry to conshim.",
-		Long:  "Adds a registry to the local conshim configuration.",
+		Long: `Adds a registry to the local conshim configuration. The registry is addressed by the alias given
+with --alias, such as "registry load-shim team jq", or by its URL if it has no alias. Git registries can be pinned to a branch, tag
+or commit with --ref or a "#ref" suffix, such as github.com/org/shims#v2. Registries whose manifest
+isn't at the root of the repository or directory can be added with --path, such as
+--path registries/team, which lets one repository host several registries.
+
+Private SSH registries authenticate with the SSH agent, or the key given with --ssh-key, and their host
+keys are checked against known_hosts. Private HTTP(S) registries authenticate with the token in the
+environment variable given with --token-env, or with git credential helpers with --credential-helper.
+Only the names of key files and environment variables are saved, never the secrets themselves.
+
+The source declared by the registry's manifest must match the URL it's added from, so that a registry
+can't pass itself off as another. A registry that declares another source can be added with
+--allow-source-mismatch, which records the declared source as an alias of the registry.`,
 
 		Args: func(cmd *cobra.Command, args []string) error {
 			numArgs := len(args)
@@ -27,10 +47,42 @@ var (
 		},
 
 		Run: func(cmd *cobra.Command, args []string) {
-			registry, err := registry.GetRegistry(addRegistryName)
+			url, ref := registry.ParseURL(addRegistryName)
+			if addCmdRef != "" {
+				ref = addCmdRef
+			}
+
+			alias := addCmdAlias
+			if alias == "" {
+				alias = url
+			}
+
+			options := registry.Options{
+				Ref:  ref,
+				Path: addCmdPath,
+				Auth: mergeAuthFlags(cmd, manifest.Auth{}),
+			}
+
+			if _, err := config.FindRegistry(alias); err == nil {
+				cobra.CheckErr(fmt.Errorf("registry '%s' has already been added, use update to refresh it", alias))
+			}
+
+			r, err := registry.GetRegistryWithOptions(url, options)
 			cobra.CheckErr(err)
 
-			cobra.CheckErr(config.WriteManifestToConfigDirectory(registry.GetManifest()))
+			cobra.CheckErr(checkClientVersion(r.GetManifest()))
+
+			entry := config.NewRegistryEntry(alias, url, options)
+			cobra.CheckErr(verifySource(&entry, r.GetManifest(), addCmdAllowSourceMismatch))
+			cobra.CheckErr(config.AddRegistry(entry, r.GetManifest()))
 		},
 	}
 )
+
+func init() {
+	addCmd.Flags().StringVarP(&addCmdAlias, "alias", "a", "", "the name to address the registry by, defaults to its URL")
+	addCmd.Flags().StringVar(&addCmdRef, "ref", "", "the branch, tag or commit to pin a git registry to")
+	addCmd.Flags().StringVar(&addCmdPath, "path", "", "the path of the manifest, or the directory containing it, within the registry")
+	addCmd.Flags().BoolVar(&addCmdAllowSourceMismatch, "allow-source-mismatch", false, "add the registry even if its manifest declares a source other than its URL")
+	bindAuthFlags(addCmd)
+}
@@ -2,29 +2,138 @@ package registry
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/meowfaceman/conshim/pkg/config"
 	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 )
 
+var (
+	authSSHKeyFile          string
+	authSSHKeyPassphraseEnv string
+	authKnownHostsFile      string
+	authUsername            string
+	authTokenEnv            string
+	authCredentialHelper    bool
+)
+
+// bindAuthFlags will bind flags that configure how a private registry authenticates.
+func bindAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&authSSHKeyFile, "ssh-key", "", "the private key for an SSH registry, instead of the SSH agent")
+	cmd.Flags().StringVar(&authSSHKeyPassphraseEnv, "ssh-key-passphrase-env", "", "the environment variable holding the passphrase of the SSH key")
+	cmd.Flags().StringVar(&authKnownHostsFile, "known-hosts", "", "the known_hosts file to check the SSH registry's host key against")
+	cmd.Flags().StringVar(&authUsername, "username", "", "the username for an HTTP(S) registry")
+	cmd.Flags().StringVar(&authTokenEnv, "token-env", "", "the environment variable holding the password or token for an HTTP(S) registry")
+	cmd.Flags().BoolVar(&authCredentialHelper, "credential-helper", false, "get the credentials for an HTTP(S) registry from git credential helpers")
+}
+
+// mergeAuthFlags will apply the auth flags that were passed on the command line to the auth, leaving the
+// settings of flags that weren't passed untouched.
+func mergeAuthFlags(cmd *cobra.Command, auth manifest.Auth) manifest.Auth {
+	flags := cmd.Flags()
+
+	if flags.Changed("ssh-key") {
+		auth.SSHKeyFile = authSSHKeyFile
+	}
+
+	if flags.Changed("ssh-key-passphrase-env") {
+		auth.SSHKeyPassphraseEnv = authSSHKeyPassphraseEnv
+	}
+
+	if flags.Changed("known-hosts") {
+		auth.KnownHostsFile = authKnownHostsFile
+	}
+
+	if flags.Changed("username") {
+		auth.Username = authUsername
+	}
+
+	if flags.Changed("token-env") {
+		auth.TokenEnv = authTokenEnv
+	}
+
+	if flags.Changed("credential-helper") {
+		auth.CredentialHelper = authCredentialHelper
+	}
+
+	return auth
+}
+
+// checkClientVersion will return an error if the registry's manifest needs a newer version of conshim,
+// and warn about the shims in it that do.
+func checkClientVersion(m *manifest.Manifest) error {
+	if err := m.CheckClientVersion(); err != nil {
+		return err
+	}
+
+	if newerShims := m.ShimsRequiringNewerClient(); len(newerShims) > 0 {
+		fmt.Printf("Warning: shim(s) %s require a newer version of conshim and can't be loaded until it's upgraded.\n", strings.Join(newerShims, ", "))
+	}
+
+	return nil
+}
+
+// verifySource will return an error if the manifest fetched for the registry declares a source that
+// doesn't match the registry's URL, since it could shadow the registry it claims to be. A mismatch is
+// accepted if it was accepted before for the same source, or if allow is true, in which case the declared
+// source is recorded in the entry as an alias of the registry.
+func verifySource(entry *config.RegistryEntry, m *manifest.Manifest, allow bool) error {
+	err := entry.VerifySource(m)
+
+	if err == nil {
+		return nil
+	}
+
+	if !allow {
+		return fmt.Errorf("%v; use --allow-source-mismatch if the registry is trusted to use that source", err)
+	}
+
+	fmt.Printf("Warning: %v; accepting '%s' as an alias of the registry.\n", err, m.Source)
+	entry.AcceptedSource = m.Source
+
+	return nil
+}
+
+// addOrGetRegistry will read the manifest of the registry addressed by the name, which is either its alias
+// or its URL. If it hasn't been added, the name is taken as a URL and the registry is added with the URL as
+// its alias.
 func addOrGetRegistry(registryName string) (*manifest.Manifest, error) {
-	m, err := config.ReadManifestFromConfigDirectory(registryName)
+	entry, err := config.FindRegistry(registryName)
+
+	if err == nil {
+		return config.ReadFreshManifest(entry)
+	}
+
+	if registry.Offline {
+		return nil, errors.Wrapf(registry.ErrOffline, "registry '%s' isn't cached and can't be fetched", registryName)
+	}
+
+	fmt.Printf("Error finding manifest for registry '%s', attempting to get it.\n", registryName)
+
+	url, ref := registry.ParseURL(registryName)
+	options := registry.Options{Ref: ref}
+
+	r, err := registry.GetRegistryWithOptions(url, options)
 
 	if err != nil {
-		fmt.Printf("Error finding manifest for registry '%s', attempting to get it.", registryName)
-		registry, err := registry.GetRegistry(registryName)
+		return nil, err
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	if versionErr := checkClientVersion(r.GetManifest()); versionErr != nil {
+		return nil, versionErr
+	}
 
-		if writeErr := config.WriteManifestToConfigDirectory(registry.GetManifest()); writeErr != nil {
-			return nil, writeErr
-		}
+	entry = config.NewRegistryEntry(url, url, options)
+	if sourceErr := verifySource(&entry, r.GetManifest(), false); sourceErr != nil {
+		return nil, sourceErr
+	}
 
-		m = registry.GetManifest()
+	if addErr := config.AddRegistry(entry, r.GetManifest()); addErr != nil {
+		return nil, addErr
 	}
 
-	return m, nil
+	return r.GetManifest(), nil
 }
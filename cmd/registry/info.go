@@ -0,0 +1,51 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	infoRegistryName string
+
+	infoCmd = &cobra.Command{
+		Use:   "info <registry>",
+		Short: "Gets info about a registry.",
+		Long:  "Prints out where a registry was fetched from, including its pinned ref and resolved commit.",
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			infoRegistryName = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			entry, err := config.FindRegistry(infoRegistryName)
+			cobra.CheckErr(err)
+
+			m, err := config.ReadRegistryManifest(entry)
+			cobra.CheckErr(err)
+
+			fmt.Printf("     Alias: %s\n", entry.Alias)
+			fmt.Printf("    Source: %s\n", m.Source)
+
+			if entry.AcceptedSource != "" {
+				fmt.Printf("            (accepted as an alias of %s)\n", entry.URL)
+			}
+
+			fmt.Printf("   Version: %s\n", m.Version)
+			fmt.Printf("     Shims: %d\n", len(m.Shims))
+
+			if m.Origin != nil {
+				fmt.Print(m.Origin)
+			}
+		},
+	}
+)
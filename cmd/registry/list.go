@@ -2,6 +2,8 @@ package registry
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/meowfaceman/conshim/pkg/config"
 	"github.com/spf13/cobra"
@@ -11,18 +13,23 @@ var (
 	listCmd = &cobra.Command{
 		Use:   "list",
 		Short: "Lists registries that have been added to conshim.",
-		Long:  "Lists registries that are currently present in conshim's local configuration.",
+		Long:  "Lists registries that are currently present in conshim's local configuration, by alias.",
 
 		Run: func(cmd *cobra.Command, args []string) {
-			registryManifests, err := config.ListRegistryManifestFiles()
+			entries, err := config.ListRegistries()
 			cobra.CheckErr(err)
 
-			for _, manifest := range registryManifests {
-				m, err := config.ReadManifestFromRawFileInConfigDirectory(manifest)
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "ALIAS\tURL\tVERSION")
+
+			for _, entry := range entries {
+				m, err := config.ReadRegistryManifest(entry)
 				cobra.CheckErr(err)
 
-				fmt.Printf("%s (%s)\n", m.Source, m.Version)
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", entry.Alias, entry.Location(), m.Version)
 			}
+
+			cobra.CheckErr(writer.Flush())
 		},
 	}
 )
@@ -3,11 +3,13 @@ package registry
 import (
 	"fmt"
 
+	"github.com/meowfaceman/conshim/pkg/shim"
 	"github.com/spf13/cobra"
 )
 
 var (
 	listShimsCmdRegistryName string
+	listShimsCmdCompatible   bool
 
 	listShimsCmd = &cobra.Command{
 		Use:   "list-shims <registry>",
@@ -30,7 +32,15 @@ var (
 			m, err := addOrGetRegistry(listShimsCmdRegistryName)
 			cobra.CheckErr(err)
 
+			if listShimsCmdCompatible {
+				m = m.FilterShims(shim.Shim.IsCompatibleWithHost)
+			}
+
 			fmt.Print(m.ShimsToString())
 		},
 	}
 )
+
+func init() {
+	listShimsCmd.Flags().BoolVar(&listShimsCmdCompatible, "compatible", false, "only list shims compatible with this host")
+}
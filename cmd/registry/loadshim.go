@@ -35,15 +35,8 @@ var (
 			m, err := addOrGetRegistry(loadShimCmdRegistryName)
 			cobra.CheckErr(err)
 
-			if manifestShim, ok := m.GetShim(loadShimCmdShimName); ok {
-				renderedShim, err := manifestShim.RenderShim(loadShimCmdParameters)
-				cobra.CheckErr(err)
-
-				if loadShimCmdUpdate {
-					cobra.CheckErr(config.Directory().UpdateBinFile(loadShimCmdShimName, []byte(renderedShim)))
-				} else {
-					cobra.CheckErr(config.Directory().AddBinFile(loadShimCmdShimName, []byte(renderedShim)))
-				}
+			if _, ok := m.GetShim(loadShimCmdShimName); ok {
+				cobra.CheckErr(config.LoadShim(m, loadShimCmdShimName, loadShimCmdParameters, loadShimCmdUpdate))
 			} else {
 				fmt.Printf("No shim '%s' was found in registry %s.\n", loadShimCmdShimName, loadShimCmdRegistryName)
 			}
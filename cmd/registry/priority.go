@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	priorityCmdClear bool
+
+	priorityCmd = &cobra.Command{
+		Use:   "priority [registry...]",
+		Short: "Shows or sets the order registries are searched for shims to install.",
+		Long: `Shows or sets the priority list of registries, addressed by their alias or URL, highest priority
+first. Unqualified shim names given to 'conshim install' are resolved against the registries in the
+priority list in order, and then against the rest of the registries. A shim provided by several
+registries outside the priority list, and by none in it, is ambiguous and has to be installed as
+'<registry>/<shim>'.
+
+Without arguments, the registries are listed in the order they are searched. With arguments, the
+priority list is replaced. Use --clear to empty it.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			if priorityCmdClear && len(args) != 0 {
+				return fmt.Errorf("expected 0 arguments with --clear, got %d", len(args))
+			}
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if priorityCmdClear || len(args) > 0 {
+				cobra.CheckErr(config.SetRegistryPriority(args))
+			}
+
+			prioritized, others, err := config.ListRegistriesByPriority()
+			cobra.CheckErr(err)
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "PRIORITY\tALIAS\tURL")
+
+			for idx, entry := range prioritized {
+				fmt.Fprintf(writer, "%d\t%s\t%s\n", idx+1, entry.Alias, entry.URL)
+			}
+
+			for _, entry := range others {
+				fmt.Fprintf(writer, "-\t%s\t%s\n", entry.Alias, entry.URL)
+			}
+
+			cobra.CheckErr(writer.Flush())
+		},
+	}
+)
+
+func init() {
+	priorityCmd.Flags().BoolVar(&priorityCmdClear, "clear", false, "empty the priority list")
+}
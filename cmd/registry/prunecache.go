@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneCacheAll bool
+
+	pruneCacheCmd = &cobra.Command{
+		Use:   "prune-cache",
+		Short: "Removes cached registry clones that are no longer needed.",
+		Long: `Removes the cached clones of git registries that are no longer in the local conshim configuration,
+along with clones left behind by interrupted fetches. With --all, every cached clone is removed, and
+registries are cloned afresh the next time they're updated.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 0 {
+				return fmt.Errorf("expected 0 arguments, got %d", numArgs)
+			}
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			keepURLs := []string{}
+
+			if !pruneCacheAll {
+				entries, err := config.ListRegistries()
+				cobra.CheckErr(err)
+
+				for _, entry := range entries {
+					keepURLs = append(keepURLs, entry.URL)
+				}
+			}
+
+			removed, err := registry.PruneCache(keepURLs)
+
+			for _, cachePath := range removed {
+				fmt.Printf("Removed %s\n", cachePath)
+			}
+
+			cobra.CheckErr(err)
+
+			fmt.Printf("Removed %d cached clone(s) from %s.\n", len(removed), config.Directory().GetCachePath())
+		},
+	}
+)
+
+func init() {
+	pruneCacheCmd.Flags().BoolVar(&pruneCacheAll, "all", false, "remove every cached clone, including those of configured registries")
+}
@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	removeRegistryName string
+
+	removeCmd = &cobra.Command{
+		Use:   "remove <registry>",
+		Short: "Removes a registry from conshim.",
+		Long: `Removes a registry, addressed by its alias or URL, and its cached manifest from the local conshim
+configuration. Shims already loaded from the registry are left in place.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 1 {
+				return fmt.Errorf("expected 1 argument, got %d", numArgs)
+			}
+
+			removeRegistryName = args[0]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			entry, err := config.RemoveRegistry(removeRegistryName)
+			cobra.CheckErr(err)
+
+			fmt.Printf("Removed registry '%s' (%s).\n", entry.Alias, entry.URL)
+		},
+	}
+)
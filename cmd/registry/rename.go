@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renameRegistryName string
+	renameNewAlias     string
+
+	renameCmd = &cobra.Command{
+		Use:   "rename <registry> <new-alias>",
+		Short: "Renames a registry in conshim.",
+		Long:  "Changes the alias a registry, addressed by its current alias or URL, is addressed by.",
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			numArgs := len(args)
+			if numArgs != 2 {
+				return fmt.Errorf("expected 2 arguments, got %d", numArgs)
+			}
+
+			renameRegistryName = args[0]
+			renameNewAlias = args[1]
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(config.RenameRegistry(renameRegistryName, renameNewAlias))
+		},
+	}
+)
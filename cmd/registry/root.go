@@ -14,9 +14,14 @@ var (
 
 func init() {
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(listShimsCmd)
 	rootCmd.AddCommand(loadShimCmd)
+	rootCmd.AddCommand(priorityCmd)
+	rootCmd.AddCommand(pruneCacheCmd)
+	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(renameCmd)
 	rootCmd.AddCommand(updateCmd)
 }
 
@@ -1,22 +1,58 @@
 package registry
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/meowfaceman/conshim/pkg/registry"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// defaultUpdateParallelism is how many registries are fetched at once by default.
+	defaultUpdateParallelism = 4
+)
+
 var (
 	updateRegistryName string
-	updateCmd          = &cobra.Command{
-		Use:   "update <registry-name>",
+	updateCmdRef       string
+	updateCmdAll       bool
+	updateCmdParallel  int
+
+	updateCmdAllowSourceMismatch bool
+
+	updateCmd = &cobra.Command{
+		Use:   "update <registry>",
 		Short: "Updates a registry in conshim.",
-		Long:  "Updates a registry already present in the local conshim configuration.",
+		Long: `Updates a registry already present in the local conshim configuration, addressed by its alias or
+URL. Registries pinned to a ref stay pinned to it unless --ref is given to change the pin, and the
+manifest is read from the same path within the registry it was added with. The registry authenticates
+as it was added, and any auth flags given change how it authenticates from then on. A registry whose
+manifest starts declaring a source other than its URL is refused unless --allow-source-mismatch is
+given.
+
+With --all, every registry is updated, fetching up to --parallel registries at once, and a summary of
+the shims added, removed and changed in each registry is printed. A registry that fails to update
+doesn't stop the others from updating.`,
 
 		Args: func(cmd *cobra.Command, args []string) error {
 			numArgs := len(args)
+
+			if updateCmdAll {
+				if numArgs != 0 {
+					return fmt.Errorf("expected 0 arguments with --all, got %d", numArgs)
+				}
+
+				return nil
+			}
+
 			if numArgs != 1 {
 				return fmt.Errorf("expected 1 argument, got %d", numArgs)
 			}
@@ -27,10 +63,152 @@ var (
 		},
 
 		Run: func(cmd *cobra.Command, args []string) {
-			registry, err := registry.GetRegistry(updateRegistryName)
+			if updateCmdAll {
+				for _, flag := range []string{"ref", "ssh-key", "ssh-key-passphrase-env", "known-hosts", "username", "token-env", "credential-helper"} {
+					if cmd.Flags().Changed(flag) {
+						cobra.CheckErr(fmt.Errorf("--%s can't be used with --all", flag))
+					}
+				}
+
+				updateAllRegistries()
+
+				return
+			}
+
+			entry, err := config.FindRegistry(updateRegistryName)
 			cobra.CheckErr(err)
 
-			cobra.CheckErr(config.UpdateManifestInConfigDirectory(registry.GetManifest()))
+			options := entry.Options()
+			if cmd.Flags().Changed("ref") {
+				options.Ref = updateCmdRef
+			}
+
+			options.Auth = mergeAuthFlags(cmd, options.Auth)
+
+			r, err := registry.GetRegistryWithOptions(entry.URL, options)
+			cobra.CheckErr(err)
+
+			entry.SetOptions(options)
+			cobra.CheckErr(saveUpdatedRegistry(entry, r.GetManifest(), updateCmdAllowSourceMismatch))
 		},
 	}
 )
+
+func init() {
+	updateCmd.Flags().StringVar(&updateCmdRef, "ref", "", "change the branch, tag or commit the git registry is pinned to, empty for the default branch")
+	updateCmd.Flags().BoolVar(&updateCmdAllowSourceMismatch, "allow-source-mismatch", false, "update the registry even if its manifest declares a source other than its URL")
+	updateCmd.Flags().BoolVar(&updateCmdAll, "all", false, "update every registry")
+	updateCmd.Flags().IntVar(&updateCmdParallel, "parallel", defaultUpdateParallelism, "how many registries to fetch at once with --all")
+	bindAuthFlags(updateCmd)
+}
+
+// saveUpdatedRegistry will check the fetched manifest of the registry and replace its cached manifest. A
+// manifest declaring a new source other than the registry's URL is only saved if allowSourceMismatch is true.
+func saveUpdatedRegistry(entry config.RegistryEntry, m *manifest.Manifest, allowSourceMismatch bool) error {
+	if err := checkClientVersion(m); err != nil {
+		return err
+	}
+
+	if err := verifySource(&entry, m, allowSourceMismatch); err != nil {
+		return err
+	}
+
+	return config.UpdateRegistry(entry, m)
+}
+
+// updateAllRegistries will fetch every registry concurrently, then save them one at a time and print a
+// summary of what changed. Interrupting stops registries that haven't started fetching.
+func updateAllRegistries() {
+	entries, err := config.ListRegistries()
+	cobra.CheckErr(err)
+
+	if len(entries) == 0 {
+		fmt.Println("No registries have been added.")
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	requests := []registry.FetchRequest{}
+	for _, entry := range entries {
+		requests = append(requests, registry.FetchRequest{Name: entry.Alias, URL: entry.URL, Options: entry.Options()})
+	}
+
+	fetched := 0
+	results := registry.FetchAll(ctx, requests, updateCmdParallel, func(result registry.FetchResult) {
+		fetched++
+
+		status := fmt.Sprintf("fetched in %s", result.Duration.Round(time.Millisecond))
+		if result.Err != nil {
+			status = "failed"
+		}
+
+		fmt.Printf("[%d/%d] %s: %s\n", fetched, len(requests), result.Request.Name, status)
+	})
+
+	summaries := []string{}
+	failures := []string{}
+
+	for idx, result := range results {
+		entry := entries[idx]
+
+		if result.Err == nil {
+			before, readErr := config.ReadRegistryManifest(entry)
+			if readErr != nil {
+				before = manifest.CreateManifest(entry.URL)
+			}
+
+			result.Err = saveUpdatedRegistry(entry, result.Registry.GetManifest(), updateCmdAllowSourceMismatch)
+
+			if result.Err == nil {
+				summaries = append(summaries, fmt.Sprintf("  %s: %s", entry.Alias, summarizeChanges(manifest.Diff(before, result.Registry.GetManifest()))))
+			}
+		}
+
+		if result.Err != nil {
+			failures = append(failures, fmt.Sprintf("  %s: %v", entry.Alias, result.Err))
+		}
+	}
+
+	if len(summaries) > 0 {
+		fmt.Println("\nUpdated:")
+		fmt.Println(strings.Join(summaries, "\n"))
+	}
+
+	if len(failures) > 0 {
+		fmt.Println("\nFailed:")
+		fmt.Println(strings.Join(failures, "\n"))
+
+		cobra.CheckErr(fmt.Errorf("%d of %d registries failed to update", len(failures), len(entries)))
+	}
+}
+
+// summarizeChanges will describe the shims added, removed and changed in a registry.
+func summarizeChanges(changes map[string]string) string {
+	if len(changes) == 0 {
+		return "no changes"
+	}
+
+	byAction := map[string][]string{}
+	for shimName, action := range changes {
+		byAction[action] = append(byAction[action], shimName)
+	}
+
+	parts := []string{}
+	for _, action := range []struct {
+		action string
+		verb   string
+	}{
+		{manifest.ActionAdd, "added"},
+		{manifest.ActionRemove, "removed"},
+		{manifest.ActionUpdate, "changed"},
+	} {
+		if shimNames := byAction[action.action]; len(shimNames) > 0 {
+			sort.Strings(shimNames)
+			parts = append(parts, fmt.Sprintf("%d %s (%s)", len(shimNames), action.verb, strings.Join(shimNames, ", ")))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/meowfaceman/conshim/cmd/manifest"
 	"github.com/meowfaceman/conshim/cmd/registry"
 	"github.com/meowfaceman/conshim/cmd/shim"
+	conshimregistry "github.com/meowfaceman/conshim/pkg/registry"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -18,7 +19,12 @@ the local environment.`,
 )
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&conshimregistry.Offline, "offline", conshimregistry.Offline, "never fetch registries or pull images over the network, only use what is cached")
+
 	rootCmd.AddCommand(binPathCmd)
+	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(versionCmd)
 
 	rootCmd.AddCommand(manifest.Root())
 	rootCmd.AddCommand(registry.Root())
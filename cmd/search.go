@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/meowfaceman/conshim/pkg/config"
+	"github.com/meowfaceman/conshim/pkg/search"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchCmd = &cobra.Command{
+		Use:   "search <query>",
+		Short: "Searches registries for shims.",
+		Long: `Searches the names, descriptions, tags and categories of the shims in every registry
+that has been added to conshim. Results are ranked by relevance.`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("required 1 or more arguments, got %d", len(args))
+			}
+
+			return nil
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			manifests, err := config.ReadFreshManifests()
+			cobra.CheckErr(err)
+
+			results := search.Search(manifests, strings.Join(args, " "))
+
+			if len(results) == 0 {
+				fmt.Println("No shims matched the query.")
+				return
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "NAME\tREGISTRY\tDESCRIPTION")
+
+			for _, result := range results {
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", result.Shim.Name, result.Registry, result.Shim.Description)
+			}
+
+			cobra.CheckErr(writer.Flush())
+		},
+	}
+)
@@ -4,14 +4,19 @@ go 1.16
 
 require (
 	github.com/andybalholm/brotli v1.0.3
+	github.com/evanphx/json-patch/v5 v5.6.0
 	github.com/go-git/go-git/v5 v5.4.2
 	github.com/go-test/deep v1.0.7
 	github.com/gofrs/flock v0.8.0
 	github.com/gopherjs/gopherjs v0.0.0-20190910122728-9d188e94fb99 // indirect
 	github.com/hashicorp/go-multierror v1.1.1
+	github.com/klauspost/compress v1.13.6
 	github.com/pkg/errors v0.9.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.2.0
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/viper v1.7.0
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/zap v1.10.0
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b
+	gopkg.in/yaml.v3 v3.0.1
 )
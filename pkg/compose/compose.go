@@ -0,0 +1,222 @@
+package compose
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/container"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the subset of a docker-compose file that's relevant to shims.
+type File struct {
+	// Services are the services defined in the compose file, keyed by service name.
+	Services map[string]Service `yaml:"services"`
+
+	// Dir is the directory relative volume sources are resolved against. If it's empty, which it is unless
+	// it's set, they're relative to the directory the shim is run from, since shims in a published manifest
+	// run on machines where the compose file's directory doesn't exist.
+	Dir string `yaml:"-"`
+}
+
+// Service is the subset of a docker-compose service that's relevant to shims.
+type Service struct {
+	// Image is the image the service runs.
+	Image string `yaml:"image"`
+
+	// Volumes are the volumes mounted into the service, in short syntax.
+	Volumes volumes `yaml:"volumes"`
+
+	// Environment are the environment variables of the service in the form "KEY=VALUE" or "KEY".
+	Environment environment `yaml:"environment"`
+
+	// WorkingDir is the working directory of the service.
+	WorkingDir string `yaml:"working_dir"`
+
+	// Entrypoint overrides the image's entrypoint.
+	Entrypoint stringOrList `yaml:"entrypoint"`
+
+	// Command overrides the image's command.
+	Command stringOrList `yaml:"command"`
+}
+
+// Parse will parse a docker-compose file.
+func Parse(reader io.Reader) (*File, error) {
+	file := &File{}
+
+	if err := yaml.NewDecoder(reader).Decode(file); err != nil {
+		return nil, errors.Wrap(err, "error parsing compose file")
+	}
+
+	if len(file.Services) == 0 {
+		return nil, errors.New("compose file has no services")
+	}
+
+	return file, nil
+}
+
+// ParseFile will parse the docker-compose file at the path.
+func ParseFile(path string) (*File, error) {
+	reader, err := os.Open(path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening compose file")
+	}
+
+	file, err := Parse(reader)
+
+	if closeErr := reader.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "error closing compose file")
+	}
+
+	return file, err
+}
+
+// ServiceNames will return the sorted names of the services in the compose file.
+func (f *File) ServiceNames() []string {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Shim will create a manifest shim that runs the service with the given runtime.
+func (f *File) Shim(serviceName string, runtime *container.Runtime) (shim.Shim, error) {
+	service, ok := f.Services[serviceName]
+
+	if !ok {
+		return shim.Shim{}, fmt.Errorf("compose file has no service '%s'", serviceName)
+	}
+
+	if service.Image == "" {
+		return shim.Shim{}, fmt.Errorf("service '%s' has no image, services that are only built aren't supported", serviceName)
+	}
+
+	command := runtime.RunCommand(container.RunSpec{
+		Image:       service.Image,
+		Volumes:     service.Volumes,
+		BaseDir:     f.Dir,
+		Environment: service.Environment,
+		WorkingDir:  service.WorkingDir,
+		Entrypoint:  service.Entrypoint,
+		Args:        service.Command,
+	})
+
+	return shim.Shim{
+		Description: fmt.Sprintf("Runs the %s docker-compose service.", serviceName),
+		Parameters:  shim.DetectParameters(command),
+		Command:     command,
+		Image:       service.Image,
+	}, nil
+}
+
+// stringOrList is a compose field that can either be a string or a list of strings. Strings are split
+// into words.
+type stringOrList []string
+
+// UnmarshalYAML will unmarshal a string or a list of strings.
+func (s *stringOrList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = strings.Fields(node.Value)
+		return nil
+	}
+
+	list := []string{}
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+
+	*s = list
+
+	return nil
+}
+
+// environment is a compose environment, which can either be a list of "KEY=VALUE" strings or a mapping.
+type environment []string
+
+// UnmarshalYAML will unmarshal a list or a mapping of environment variables.
+func (e *environment) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		list := []string{}
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+
+		*e = list
+
+		return nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: environment must be a list or a mapping", node.Line)
+	}
+
+	// Mapping nodes alternate between keys and values. A null value passes the variable through.
+	env := []string{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if value.Tag == "!!null" {
+			env = append(env, key.Value)
+		} else {
+			env = append(env, key.Value+"="+value.Value)
+		}
+	}
+
+	*e = env
+
+	return nil
+}
+
+// volumes are compose volumes, which can be in either short or long syntax. Long syntax volumes are
+// converted to short syntax.
+type volumes []string
+
+// UnmarshalYAML will unmarshal a list of short or long syntax volumes.
+func (v *volumes) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: volumes must be a list", node.Line)
+	}
+
+	list := []string{}
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			list = append(list, item.Value)
+			continue
+		}
+
+		longSyntax := struct {
+			Source   string `yaml:"source"`
+			Target   string `yaml:"target"`
+			ReadOnly bool   `yaml:"read_only"`
+		}{}
+
+		if err := item.Decode(&longSyntax); err != nil {
+			return err
+		}
+
+		volume := longSyntax.Target
+		if longSyntax.Source != "" {
+			volume = longSyntax.Source + ":" + volume
+		}
+
+		if longSyntax.ReadOnly {
+			volume += ":ro"
+		}
+
+		list = append(list, volume)
+	}
+
+	*v = list
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/container"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShim(t *testing.T) {
+	tests := []struct {
+		name        string
+		service     string
+		dir         string
+		expected    shim.Shim
+		expectedErr bool
+	}{
+		{
+			name:    "mapping environment and long syntax volumes",
+			service: "node",
+			expected: shim.Shim{
+				Description: "Runs the node docker-compose service.",
+				Parameters:  []string{},
+				Command: `docker run --rm -i -v "$PWD/:/src" -v npm-cache:/root/.npm -v "$PWD/config:/config:ro" ` +
+					`-e NODE_ENV=development -e NPM_TOKEN -w /src --entrypoint node {{image}} "$@"`,
+				Image: "node:20",
+			},
+		},
+		{
+			name:    "list environment, entrypoint and command",
+			service: "terraform",
+			expected: shim.Shim{
+				Description: "Runs the terraform docker-compose service.",
+				Parameters:  []string{},
+				Command: `docker run --rm -i -v "$PWD:/workspace" -e TF_LOG=info -e AWS_PROFILE -w /workspace ` +
+					`--entrypoint terraform {{image}} -chdir=/workspace "$@"`,
+				Image: "hashicorp/terraform:1.5",
+			},
+		},
+		{
+			name:    "volumes resolved against a directory",
+			service: "terraform",
+			dir:     "/home/user/project",
+			expected: shim.Shim{
+				Description: "Runs the terraform docker-compose service.",
+				Parameters:  []string{},
+				Command: `docker run --rm -i -v /home/user/project:/workspace -e TF_LOG=info -e AWS_PROFILE -w /workspace ` +
+					`--entrypoint terraform {{image}} -chdir=/workspace "$@"`,
+				Image: "hashicorp/terraform:1.5",
+			},
+		},
+		{
+			name:        "build only service",
+			service:     "app",
+			expectedErr: true,
+		},
+		{
+			name:        "missing service",
+			service:     "missing",
+			expectedErr: true,
+		},
+	}
+
+	file, err := ParseFile(filepath.Join("testdata", "docker-compose.yml"))
+	assert.NoError(t, err, "should be no error parsing fixture")
+	assert.Equal(t, []string{"app", "node", "terraform"}, file.ServiceNames(), "service names should match")
+
+	for _, test := range tests {
+		file.Dir = test.dir
+		s, err := file.Shim(test.service, container.NewRuntime("docker"))
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+		if !test.expectedErr {
+			assert.Equal(t, test.expected, s, "%s: shims should match", test.name)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "invalid.yml"))
+	assert.NoError(t, err, "should be no error opening fixture")
+
+	defer func() {
+		assert.NoError(t, f.Close(), "should be no error closing fixture")
+	}()
+
+	_, err = Parse(f)
+	assert.Error(t, err, "invalid environment should error")
+
+	_, err = Parse(strings.NewReader("version: '3'\n"))
+	assert.Error(t, err, "compose file without services should error")
+}
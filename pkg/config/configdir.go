@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 
 	"github.com/gofrs/flock"
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/meowfaceman/conshim/pkg/shim"
 	"github.com/meowfaceman/conshim/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -24,6 +26,8 @@ type ConfigDirectory struct {
 	lock         *flock.Flock
 	binPath      string
 	registryPath string
+	indexPath    string
+	cachePath    string
 }
 
 var (
@@ -45,6 +49,8 @@ func init() {
 	if err != nil {
 		panic(fmt.Sprintf("error creating config directory: %v", err))
 	}
+
+	registry.CacheDirectory = configDir.cachePath
 }
 
 // newConfigDirectory will create a config directory object, creating the actual config directory
@@ -55,6 +61,10 @@ func newConfigDirectory() (*ConfigDirectory, error) {
 	lockFile := filepath.Join(configDirPath, "lock")
 	binPath := filepath.Join(configDirPath, "bin")
 	registryPath := filepath.Join(configDirPath, "registries")
+	indexPath := filepath.Join(configDirPath, "registries.json")
+
+	// Cached registry clones live outside of the registry directory, which only holds manifests.
+	cachePath := filepath.Join(configDirPath, "cache")
 
 	// Make the bin directory if it doesn't already exist.
 	if err := os.MkdirAll(binPath, 0700); err != nil {
@@ -70,6 +80,8 @@ func newConfigDirectory() (*ConfigDirectory, error) {
 		lock:         flock.New(lockFile),
 		binPath:      binPath,
 		registryPath: registryPath,
+		indexPath:    indexPath,
+		cachePath:    cachePath,
 	}, nil
 }
 
@@ -90,6 +102,10 @@ func (c *ConfigDirectory) GetBinPath() string {
 
 // AddBinFile will add an executable file to the bin directory.
 func (c *ConfigDirectory) AddBinFile(filename string, data []byte) error {
+	if err := shim.ValidateName(filename); err != nil {
+		return err
+	}
+
 	if err := c.getLock(); err != nil {
 		return errors.Wrap(err, "error getting lock while adding bin file")
 	}
@@ -111,6 +127,10 @@ func (c *ConfigDirectory) AddBinFile(filename string, data []byte) error {
 
 // AddBinFile will update an executable file to the bin directory.
 func (c *ConfigDirectory) UpdateBinFile(filename string, data []byte) error {
+	if err := shim.ValidateName(filename); err != nil {
+		return err
+	}
+
 	if err := c.getLock(); err != nil {
 		return errors.Wrap(err, "error getting lock while adding bin file")
 	}
@@ -130,6 +150,11 @@ func (c *ConfigDirectory) UpdateBinFile(filename string, data []byte) error {
 	return nil
 }
 
+// GetCachePath will return the path of the registry clone cache for this config directory.
+func (c *ConfigDirectory) GetCachePath() string {
+	return c.cachePath
+}
+
 // GetBinFile will return the full path name of a bin file.
 func (c *ConfigDirectory) GetBinFileName(binFileName string) string {
 	return filepath.Join(c.binPath, binFileName)
@@ -162,99 +187,6 @@ func (c *ConfigDirectory) ListBinFiles() ([]string, error) {
 	return shims, nil
 }
 
-// CreateRegistryFile will create a registry file with the given name. It's up to the caller to close this file.
-func (c *ConfigDirectory) CreateRegistryFile(name string) (*os.File, error) {
-	if err := c.getLock(); err != nil {
-		return nil, errors.Wrap(err, "error getting lock while creating registry file")
-	}
-	defer c.unlock()
-
-	registryFilePath := filepath.Join(c.registryPath, name)
-
-	// Add if the file doesn't exist
-	if _, err := os.Stat(registryFilePath); err == nil {
-		return nil, fmt.Errorf("can't create registry file '%s' because it already exists", registryFilePath)
-	}
-
-	file, err := os.OpenFile(registryFilePath, os.O_CREATE|os.O_WRONLY, 0600)
-
-	if err != nil {
-		return nil, errors.Wrap(err, "error while creating registry file")
-	}
-
-	return file, nil
-}
-
-// UpdateRegistryFile will update an existing registry file. It's up to the caller to close this file.
-func (c *ConfigDirectory) UpdateRegistryFile(name string) (*os.File, error) {
-	if err := c.getLock(); err != nil {
-		return nil, errors.Wrap(err, "error getting lock while creating registry file")
-	}
-	defer c.unlock()
-
-	registryFilePath := filepath.Join(c.registryPath, name)
-
-	// Update if the file does exist
-	if _, err := os.Stat(registryFilePath); err != nil {
-		return nil, fmt.Errorf("can't create registry file '%s' because it doesn't exist", registryFilePath)
-	}
-
-	file, err := os.OpenFile(registryFilePath, os.O_TRUNC|os.O_WRONLY, 0600)
-
-	if err != nil {
-		return nil, errors.Wrap(err, "error while creating registry file")
-	}
-
-	return file, nil
-}
-
-// GetRegistryFile will get the registry file.
-func (c *ConfigDirectory) GetRegistryFile(sourceName string) (*os.File, func(), error) {
-	if err := c.getLock(); err != nil {
-		return nil, nil, errors.Wrap(err, "error getting lock while creating registry file")
-	}
-	defer c.unlock()
-
-	file, err := os.Open(filepath.Join(c.registryPath, sourceName))
-
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "error while opening registry file")
-	}
-
-	return file, func() {
-		if closeErr := file.Close(); closeErr != nil {
-			zap.S().Errorf("error while closing registry file: %v", closeErr)
-		}
-	}, nil
-}
-
-// ListRegistryFiles will return the list of the files in the registry directory.
-func (c *ConfigDirectory) ListRegistryFiles() ([]string, error) {
-	if err := c.getLock(); err != nil {
-		return nil, errors.Wrap(err, "error getting lock while listing registry files")
-	}
-	defer c.unlock()
-
-	var registryManifests []string
-
-	err := filepath.Walk(c.registryPath, func(path string, info fs.FileInfo, err error) error {
-		// If we find a directory, skip it. We only care about shim files here.
-		if info.IsDir() {
-			return nil
-		}
-
-		registryManifests = append(registryManifests, info.Name())
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, errors.Wrap(err, "error while listing registry file directory")
-	}
-
-	return registryManifests, nil
-}
-
 func (c *ConfigDirectory) getLock() error {
 	locked, err := c.lock.TryLock()
 
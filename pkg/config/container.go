@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/meowfaceman/conshim/pkg/container"
+	"github.com/meowfaceman/conshim/pkg/utils"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConshimContainerRuntime is the container runtime executable used to inspect images.
+	ConshimContainerRuntime = "conshim.container.runtime"
+)
+
+func init() {
+	utils.Must(viper.BindEnv(ConshimContainerRuntime, "CONSHIM_CONTAINER_RUNTIME"))
+	viper.SetDefault(ConshimContainerRuntime, container.DefaultRuntime)
+}
+
+// ContainerRuntime returns the configured container runtime.
+func ContainerRuntime() *container.Runtime {
+	return container.NewRuntime(viper.GetString(ConshimContainerRuntime))
+}
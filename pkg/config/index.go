@@ -0,0 +1,396 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/meowfaceman/conshim/pkg/utils"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// RegistryEntry is a registry that has been added to conshim, as recorded in the registry index.
+type RegistryEntry struct {
+	// Alias is the name the registry is addressed by.
+	Alias string `json:"alias"`
+
+	// URL is the URL the registry is fetched from.
+	URL string `json:"url"`
+
+	// Ref is the branch, tag or commit a git registry is pinned to.
+	Ref string `json:"ref,omitempty"`
+
+	// Path is the path of the manifest within the registry.
+	Path string `json:"path,omitempty"`
+
+	// Auth is how the registry authenticates, or nil for the defaults.
+	Auth *manifest.Auth `json:"auth,omitempty"`
+
+	// AcceptedSource is the source the registry's manifest declares when it doesn't match the registry's
+	// URL and the mismatch was accepted. The declared source is then an alias of the URL, and only a
+	// manifest declaring the same source is accepted from the registry without asking again.
+	AcceptedSource string `json:"acceptedSource,omitempty"`
+
+	// FetchedAt is when the registry's manifest was last fetched, or zero if it isn't known.
+	FetchedAt time.Time `json:"fetchedAt"`
+
+	// ManifestFile is the name of the file in the registry directory holding the cached manifest. It's
+	// assigned when the registry is added and doesn't change when the registry is renamed.
+	ManifestFile string `json:"manifestFile"`
+}
+
+// NewRegistryEntry will create an entry for the registry at the URL fetched with the options.
+func NewRegistryEntry(alias, url string, options registry.Options) RegistryEntry {
+	entry := RegistryEntry{Alias: alias, URL: url}
+	entry.SetOptions(options)
+
+	return entry
+}
+
+// Location will return the URL of the registry, followed by its ref if it's pinned to one.
+func (e RegistryEntry) Location() string {
+	if e.Ref == "" {
+		return e.URL
+	}
+
+	return e.URL + "#" + e.Ref
+}
+
+// Options will return the options the registry is fetched with.
+func (e RegistryEntry) Options() registry.Options {
+	options := registry.Options{Ref: e.Ref, Path: e.Path}
+	if e.Auth != nil {
+		options.Auth = *e.Auth
+	}
+
+	return options
+}
+
+// SetOptions will change the options the registry is fetched with.
+func (e *RegistryEntry) SetOptions(options registry.Options) {
+	e.Ref = options.Ref
+	e.Path = options.Path
+	e.Auth = nil
+
+	if !options.Auth.IsZero() {
+		auth := options.Auth
+		e.Auth = &auth
+	}
+}
+
+// setFetchedAt will record when the manifest of the registry was fetched.
+func (e *RegistryEntry) setFetchedAt(m *manifest.Manifest) {
+	if m.Origin != nil {
+		e.FetchedAt = m.Origin.FetchedAt
+	}
+}
+
+// VerifySource will return an error if the manifest fetched for the registry declares a source that doesn't
+// match the registry's URL, since it could shadow the registry it claims to be, unless the mismatch was
+// accepted before for the same source. A matching source clears any accepted source.
+func (e *RegistryEntry) VerifySource(m *manifest.Manifest) error {
+	err := registry.CheckSource(m.Source, e.URL, e.Options())
+
+	if err == nil {
+		e.AcceptedSource = ""
+		return nil
+	}
+
+	if m.Source == e.AcceptedSource {
+		return nil
+	}
+
+	return err
+}
+
+// RegistryIndex is the list of registries that have been added to conshim.
+type RegistryIndex struct {
+	// Registries are the added registries, in the order they were added.
+	Registries []RegistryEntry `json:"registries"`
+
+	// Priority are the aliases of the registries that unqualified shim names are resolved against first,
+	// highest priority first.
+	Priority []string `json:"priority,omitempty"`
+}
+
+// Get will return the index of the registry with the alias in the list, or -1 if there isn't one.
+func (i *RegistryIndex) Get(alias string) int {
+	for idx, entry := range i.Registries {
+		if entry.Alias == alias {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// Prioritized will return the registries in the priority list in priority order, followed by the rest of
+// the registries in the order they were added.
+func (i *RegistryIndex) Prioritized() ([]RegistryEntry, []RegistryEntry) {
+	prioritized := []RegistryEntry{}
+	inPriority := map[string]bool{}
+
+	for _, alias := range i.Priority {
+		if idx := i.Get(alias); idx >= 0 && !inPriority[alias] {
+			prioritized = append(prioritized, i.Registries[idx])
+			inPriority[alias] = true
+		}
+	}
+
+	others := []RegistryEntry{}
+	for _, entry := range i.Registries {
+		if !inPriority[entry.Alias] {
+			others = append(others, entry)
+		}
+	}
+
+	return prioritized, others
+}
+
+// renamePriority will replace the alias in the priority list, removing it if the new alias is empty.
+func (i *RegistryIndex) renamePriority(alias, newAlias string) {
+	priority := []string{}
+	for _, prioritized := range i.Priority {
+		switch {
+		case prioritized != alias:
+			priority = append(priority, prioritized)
+		case newAlias != "":
+			priority = append(priority, newAlias)
+		}
+	}
+
+	i.Priority = priority
+}
+
+// Find will return the registry addressed by the name, which is either its alias or its URL, optionally
+// with a "#ref" suffix. URLs are compared in their normalized form, so equivalent ways of writing a URL
+// find the same registry. Aliases take precedence over URLs, and URLs over accepted sources.
+func (i *RegistryIndex) Find(name string) (RegistryEntry, error) {
+	if idx := i.Get(name); idx >= 0 {
+		return i.Registries[idx], nil
+	}
+
+	url, ref := registry.ParseURL(name)
+	normalizedURL := registry.NormalizeURL(url)
+
+	matches := []RegistryEntry{}
+	for _, entry := range i.Registries {
+		if registry.NormalizeURL(entry.URL) == normalizedURL && (ref == "" || entry.Ref == ref) {
+			matches = append(matches, entry)
+		}
+	}
+
+	// Accepted sources are only aliases of last resort, so they never shadow another registry's URL.
+	if len(matches) == 0 {
+		for _, entry := range i.Registries {
+			if entry.AcceptedSource != "" && entry.AcceptedSource == name {
+				matches = append(matches, entry)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return RegistryEntry{}, fmt.Errorf("no registry '%s' has been added", name)
+	case 1:
+		return matches[0], nil
+	default:
+		aliases := []string{}
+		for _, match := range matches {
+			aliases = append(aliases, match.Alias)
+		}
+
+		return RegistryEntry{}, fmt.Errorf("registry URL '%s' was added several times, use one of the aliases %s", name, strings.Join(aliases, ", "))
+	}
+}
+
+// validateAlias will return an error if the alias can't be used to address a registry.
+func validateAlias(alias string) error {
+	if alias == "" {
+		return errors.New("registry alias can't be empty")
+	}
+
+	if strings.ContainsAny(alias, " \t\r\n") {
+		return fmt.Errorf("registry alias '%s' can't contain whitespace", alias)
+	}
+
+	return nil
+}
+
+// UpdateRegistryIndex will read the registry index, apply the update to it and write it back, holding the
+// config directory lock throughout so that concurrent changes aren't lost.
+func (c *ConfigDirectory) UpdateRegistryIndex(update func(*RegistryIndex) error) error {
+	if err := c.getLock(); err != nil {
+		return errors.Wrap(err, "error getting lock while updating registry index")
+	}
+	defer c.unlock()
+
+	index, err := c.readRegistryIndex()
+
+	if err != nil {
+		return err
+	}
+
+	if err := update(index); err != nil {
+		return err
+	}
+
+	return c.writeRegistryIndex(index)
+}
+
+// ReadRegistryIndex will read the registry index.
+func (c *ConfigDirectory) ReadRegistryIndex() (*RegistryIndex, error) {
+	if err := c.getLock(); err != nil {
+		return nil, errors.Wrap(err, "error getting lock while reading registry index")
+	}
+	defer c.unlock()
+
+	return c.readRegistryIndex()
+}
+
+// readRegistryIndex will read the registry index without locking. Config directories from before there
+// was an index get one built from their cached manifests.
+func (c *ConfigDirectory) readRegistryIndex() (*RegistryIndex, error) {
+	data, err := ioutil.ReadFile(c.indexPath)
+
+	if os.IsNotExist(err) {
+		return c.migrateRegistryIndex()
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading registry index")
+	}
+
+	index := &RegistryIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, errors.Wrap(err, "error decoding registry index")
+	}
+
+	return index, nil
+}
+
+// writeRegistryIndex will write the registry index without locking.
+func (c *ConfigDirectory) writeRegistryIndex(index *RegistryIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "error encoding registry index")
+	}
+
+	return utils.WriteFileAtomic(c.indexPath, 0600, func(writer io.Writer) error {
+		_, writeErr := writer.Write(append(data, '\n'))
+		return writeErr
+	})
+}
+
+// migrateRegistryIndex will build a registry index from the manifests cached before there was an index,
+// which were named by the hash of their source. Each registry is aliased by its source.
+func (c *ConfigDirectory) migrateRegistryIndex() (*RegistryIndex, error) {
+	index := &RegistryIndex{Registries: []RegistryEntry{}}
+
+	err := filepath.WalkDir(c.registryPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories and the temporary files of interrupted writes.
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		m, readErr := c.readRegistryFile(entry.Name())
+
+		if readErr != nil {
+			zap.S().Warnf("skipping registry manifest '%s' while building the registry index: %v", entry.Name(), readErr)
+			return nil
+		}
+
+		if index.Get(m.Source) >= 0 {
+			zap.S().Warnf("skipping registry manifest '%s' while building the registry index: registry '%s' is already indexed", entry.Name(), m.Source)
+			return nil
+		}
+
+		registryEntry := RegistryEntry{Alias: m.Source, URL: m.Source, ManifestFile: entry.Name()}
+		if m.Origin != nil {
+			registryEntry.URL = m.Origin.URL
+			registryEntry.Ref = m.Origin.Ref
+			registryEntry.Path = m.Origin.Path
+			registryEntry.Auth = m.Origin.Auth
+			registryEntry.FetchedAt = m.Origin.FetchedAt
+		}
+
+		index.Registries = append(index.Registries, registryEntry)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing registry manifests while building the registry index")
+	}
+
+	return index, nil
+}
+
+// newRegistryFileName will return an unused name for the manifest file of the registry with the alias.
+func (c *ConfigDirectory) newRegistryFileName(alias string) string {
+	name := manifest.SourceHash(alias)
+
+	for suffix := 2; ; suffix++ {
+		if _, err := os.Stat(filepath.Join(c.registryPath, name)); os.IsNotExist(err) {
+			return name
+		}
+
+		name = manifest.SourceHash(alias + "#" + strconv.Itoa(suffix))
+	}
+}
+
+// readRegistryFile will read a cached manifest from the registry directory without locking.
+func (c *ConfigDirectory) readRegistryFile(name string) (*manifest.Manifest, error) {
+	file, err := os.Open(filepath.Join(c.registryPath, name))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error while opening registry file")
+	}
+
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			zap.S().Errorf("error while closing registry file: %v", closeErr)
+		}
+	}()
+
+	m, err := manifest.ReadManifest(file)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading manifest file")
+	}
+
+	return m, nil
+}
+
+// writeRegistryFile will write a manifest to the registry directory without locking.
+func (c *ConfigDirectory) writeRegistryFile(name string, m *manifest.Manifest) error {
+	if err := utils.WriteFileAtomic(filepath.Join(c.registryPath, name), 0600, m.WriteManifest); err != nil {
+		return errors.Wrap(err, "error writing registry file")
+	}
+
+	return nil
+}
+
+// removeRegistryFile will remove a manifest from the registry directory without locking.
+func (c *ConfigDirectory) removeRegistryFile(name string) error {
+	if err := os.Remove(filepath.Join(c.registryPath, name)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "error removing registry file")
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryIndexFind(t *testing.T) {
+	index := &RegistryIndex{Registries: []RegistryEntry{
+		{Alias: "tools", URL: "github.com/org/tools"},
+		{Alias: "tools-stable", URL: "https://github.com/org/tools.git", Ref: "stable"},
+		{Alias: "github.com/org/other", URL: "github.com/org/shims"},
+		{Alias: "other", URL: "github.com/org/other"},
+		{Alias: "mirror", URL: "github.com/mirror/shims", AcceptedSource: "github.com/org/shims"},
+		{Alias: "vendored", URL: "example.com/vendored", AcceptedSource: "example.com/upstream"},
+	}}
+
+	tests := []struct {
+		name          string
+		input         string
+		expectedAlias string
+		expectedErr   bool
+	}{
+		{name: "alias", input: "tools", expectedAlias: "tools"},
+		{name: "ref", input: "github.com/org/tools#stable", expectedAlias: "tools-stable"},
+		{name: "equivalent URL with ref", input: "https://GitHub.com/org/tools.git#stable", expectedAlias: "tools-stable"},
+		{name: "unknown ref", input: "github.com/org/tools#missing", expectedErr: true},
+		{name: "URL added several times", input: "github.com/org/tools", expectedErr: true},
+		{name: "alias before URL", input: "github.com/org/other", expectedAlias: "github.com/org/other"},
+		{name: "URL before accepted source", input: "github.com/org/shims", expectedAlias: "github.com/org/other"},
+		{name: "accepted source", input: "example.com/upstream", expectedAlias: "vendored"},
+		{name: "missing", input: "missing", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		entry, err := index.Find(test.input)
+
+		if test.expectedErr {
+			assert.Error(t, err, "%s: finding should error", test.name)
+			continue
+		}
+
+		assert.NoError(t, err, "%s: finding should not error", test.name)
+		assert.Equal(t, test.expectedAlias, entry.Alias, "%s: aliases should match", test.name)
+	}
+}
+
+func TestRegistryEntryLocation(t *testing.T) {
+	index := &RegistryIndex{Registries: []RegistryEntry{
+		{Alias: "tools", URL: "github.com/org/tools"},
+		{Alias: "tools-stable", URL: "https://github.com/org/tools.git", Ref: "stable"},
+	}}
+
+	tests := []struct {
+		name     string
+		entry    RegistryEntry
+		expected string
+	}{
+		{name: "no ref", entry: index.Registries[0], expected: "github.com/org/tools"},
+		{name: "ref", entry: index.Registries[1], expected: "https://github.com/org/tools.git#stable"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.entry.Location(), "%s: locations should match", test.name)
+	}
+
+	entry, err := index.Find(index.Registries[1].Location())
+	assert.NoError(t, err, "finding by location should not error")
+	assert.Equal(t, "tools-stable", entry.Alias, "location should find the entry")
+}
+
+func TestMigrateRegistryIndex(t *testing.T) {
+	defer setTestConfigDirectory(t)()
+
+	fetchedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	fetched := manifest.CreateManifest("github.com/org/shims")
+	fetched.Origin = &manifest.Origin{URL: "https://github.com/org/shims.git", Ref: "stable", FetchedAt: fetchedAt}
+
+	writes := map[string]*manifest.Manifest{
+		manifest.SourceHash("github.com/org/shims"): fetched,
+		manifest.SourceHash("local"):                manifest.CreateManifest("local"),
+		".interrupted":                              manifest.CreateManifest("interrupted"),
+	}
+
+	for name, m := range writes {
+		assert.NoError(t, configDir.writeRegistryFile(name, m), "should be no error writing %s", name)
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(configDir.registryPath, "corrupt"), []byte("{"), 0600), "should be no error writing a corrupt manifest")
+
+	entries, err := ListRegistries()
+	assert.NoError(t, err, "listing migrated registries should not error")
+	assert.ElementsMatch(t, []RegistryEntry{
+		{
+			Alias:        "github.com/org/shims",
+			URL:          "https://github.com/org/shims.git",
+			Ref:          "stable",
+			FetchedAt:    fetchedAt,
+			ManifestFile: manifest.SourceHash("github.com/org/shims"),
+		},
+		{
+			Alias:        "local",
+			URL:          "local",
+			ManifestFile: manifest.SourceHash("local"),
+		},
+	}, entries, "manifests from before the index should be indexed")
+
+	m, err := ReadManifestFromConfigDirectory("github.com/org/shims#stable")
+	assert.NoError(t, err, "reading a migrated manifest should not error")
+	assert.Equal(t, "github.com/org/shims", m.Source, "the migrated manifest should be read")
+
+	assert.NoError(t, configDir.writeRegistryFile("duplicate", manifest.CreateManifest("local")), "should be no error writing a duplicate manifest")
+
+	entries, err = ListRegistries()
+	assert.NoError(t, err, "listing migrated registries should not error")
+	assert.Len(t, entries, 2, "duplicate sources should only be indexed once")
+}
+
+func TestNewRegistryFileName(t *testing.T) {
+	defer setTestConfigDirectory(t)()
+
+	expected := []string{
+		manifest.SourceHash("tools"),
+		manifest.SourceHash("tools#2"),
+		manifest.SourceHash("tools#3"),
+	}
+
+	for _, name := range expected {
+		assert.Equal(t, name, configDir.newRegistryFileName("tools"), "unused names should be chosen")
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(configDir.registryPath, name), []byte{}, 0600), "should be no error using %s", name)
+	}
+
+	assert.Equal(t, manifest.SourceHash("other"), configDir.newRegistryFileName("other"), "other aliases should not collide")
+}
+
+// setTestConfigDirectory will point the config directory at a temporary directory, returning a function
+// restoring the previous one.
+func setTestConfigDirectory(t *testing.T) func() {
+	previous := configDir
+	previousPath := viper.GetString(ConshimConfigDirectory)
+
+	viper.Set(ConshimConfigDirectory, t.TempDir())
+	dir, err := newConfigDirectory()
+	assert.NoError(t, err, "should be no error creating the config directory")
+	configDir = dir
+
+	return func() {
+		configDir = previous
+		viper.Set(ConshimConfigDirectory, previousPath)
+	}
+}
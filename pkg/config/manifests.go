@@ -1,83 +1,253 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/meowfaceman/conshim/pkg/utils"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
-// WriteManifestToConfigDirectory will write the manifest as a compressed, serialized file to the config directory.
-func WriteManifestToConfigDirectory(m *manifest.Manifest) error {
-	file, err := configDir.CreateRegistryFile(m.SourceHash())
+const (
+	// ConshimMaxManifestSize is the maximum decompressed size of a manifest in bytes.
+	ConshimMaxManifestSize = "conshim.manifest.max-size"
 
-	if err != nil {
-		return errors.Wrap(err, "error creating registry file")
-	}
+	// ConshimStrictManifests enables validation of manifests against the manifest JSON Schema when reading them.
+	ConshimStrictManifests = "conshim.manifest.strict"
+)
 
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			zap.S().Errorf("error closing registry file: %v", closeErr)
-		}
-	}()
+func init() {
+	utils.Must(viper.BindEnv(ConshimMaxManifestSize, "CONSHIM_MANIFEST_MAX_SIZE"))
+	viper.SetDefault(ConshimMaxManifestSize, manifest.DefaultMaxManifestSize)
+
+	manifest.MaxManifestSize = viper.GetInt64(ConshimMaxManifestSize)
+
+	utils.Must(viper.BindEnv(ConshimStrictManifests, "CONSHIM_MANIFEST_STRICT"))
+	viper.SetDefault(ConshimStrictManifests, false)
+
+	manifest.Strict = viper.GetBool(ConshimStrictManifests)
+}
 
-	if writeErr := m.WriteManifest(file); writeErr != nil {
-		return errors.Wrap(writeErr, "error writing manifest file")
+// ListRegistries will return the registries that have been added, in the order they were added.
+func ListRegistries() ([]RegistryEntry, error) {
+	index, err := configDir.ReadRegistryIndex()
+
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return index.Registries, nil
 }
 
-// UpdateManifestInConfigDirectory will update the manifest file in the config directory.
-func UpdateManifestInConfigDirectory(m *manifest.Manifest) error {
-	file, err := configDir.UpdateRegistryFile(m.SourceHash())
+// FindRegistry will return the registry addressed by the name, which is either its alias or its URL.
+func FindRegistry(name string) (RegistryEntry, error) {
+	index, err := configDir.ReadRegistryIndex()
 
 	if err != nil {
-		return errors.Wrap(err, "error updating registry file")
+		return RegistryEntry{}, err
 	}
 
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			zap.S().Errorf("error closing registry file: %v", closeErr)
+	return index.Find(name)
+}
+
+// AddRegistry will add the registry to the index and write its manifest to the config directory.
+func AddRegistry(entry RegistryEntry, m *manifest.Manifest) error {
+	if err := validateAlias(entry.Alias); err != nil {
+		return err
+	}
+
+	return configDir.UpdateRegistryIndex(func(index *RegistryIndex) error {
+		if index.Get(entry.Alias) >= 0 {
+			return fmt.Errorf("registry '%s' has already been added", entry.Alias)
+		}
+
+		entry.ManifestFile = configDir.newRegistryFileName(entry.Alias)
+		entry.setFetchedAt(m)
+
+		if err := configDir.writeRegistryFile(entry.ManifestFile, m); err != nil {
+			return err
 		}
-	}()
 
-	if writeErr := m.WriteManifest(file); writeErr != nil {
-		return errors.Wrap(writeErr, "error writing manifest file")
+		index.Registries = append(index.Registries, entry)
+
+		return nil
+	})
+}
+
+// UpdateRegistry will update the URL and options of the registry with the entry's alias, and replace its
+// manifest in the config directory.
+func UpdateRegistry(entry RegistryEntry, m *manifest.Manifest) error {
+	return configDir.UpdateRegistryIndex(func(index *RegistryIndex) error {
+		idx := index.Get(entry.Alias)
+		if idx < 0 {
+			return fmt.Errorf("no registry '%s' has been added", entry.Alias)
+		}
+
+		entry.ManifestFile = index.Registries[idx].ManifestFile
+		entry.setFetchedAt(m)
+
+		if err := configDir.writeRegistryFile(entry.ManifestFile, m); err != nil {
+			return err
+		}
+
+		index.Registries[idx] = entry
+
+		return nil
+	})
+}
+
+// RemoveRegistry will remove the registry addressed by the name and its manifest from the config
+// directory, returning the removed registry.
+func RemoveRegistry(name string) (RegistryEntry, error) {
+	removed := RegistryEntry{}
+
+	err := configDir.UpdateRegistryIndex(func(index *RegistryIndex) error {
+		entry, err := index.Find(name)
+
+		if err != nil {
+			return err
+		}
+
+		if err := configDir.removeRegistryFile(entry.ManifestFile); err != nil {
+			return err
+		}
+
+		idx := index.Get(entry.Alias)
+		index.Registries = append(index.Registries[:idx], index.Registries[idx+1:]...)
+		index.renamePriority(entry.Alias, "")
+		removed = entry
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// RenameRegistry will change the alias of the registry addressed by the name.
+func RenameRegistry(name, newAlias string) error {
+	if err := validateAlias(newAlias); err != nil {
+		return err
 	}
 
-	return nil
+	return configDir.UpdateRegistryIndex(func(index *RegistryIndex) error {
+		entry, err := index.Find(name)
+
+		if err != nil {
+			return err
+		}
+
+		if index.Get(newAlias) >= 0 {
+			return fmt.Errorf("registry '%s' has already been added", newAlias)
+		}
+
+		index.Registries[index.Get(entry.Alias)].Alias = newAlias
+		index.renamePriority(entry.Alias, newAlias)
+
+		return nil
+	})
 }
 
-// ReadManifestFromConfigDirectory will read the manifest from the config directory.
-func ReadManifestFromConfigDirectory(sourceName string) (*manifest.Manifest, error) {
-	return ReadManifestFromRawFileInConfigDirectory(manifest.SourceHash(sourceName))
+// ListRegistriesByPriority will return the registries in the priority list in priority order, followed by
+// the rest of the registries in the order they were added.
+func ListRegistriesByPriority() ([]RegistryEntry, []RegistryEntry, error) {
+	index, err := configDir.ReadRegistryIndex()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prioritized, others := index.Prioritized()
+
+	return prioritized, others, nil
 }
 
-// ReadManifestFromRawFileInConfigDirectory will read the manifest from the config directory given a raw file name.
-func ReadManifestFromRawFileInConfigDirectory(filename string) (*manifest.Manifest, error) {
-	file, closer, err := configDir.GetRegistryFile(filename)
+// ResolveShim will find the registry to install the shim from, trying the registries in the priority list
+// first. Registries whose manifest can't be read are skipped with a warning.
+func ResolveShim(shimName string) (registry.ShimProvider, []string, error) {
+	prioritized, others, err := ListRegistriesByPriority()
 
 	if err != nil {
-		return nil, errors.Wrap(err, "error opening registry file")
+		return registry.ShimProvider{}, nil, err
+	}
+
+	if len(prioritized)+len(others) == 0 {
+		return registry.ShimProvider{}, nil, fmt.Errorf("no registries have been added, add one with 'conshim registry add'")
+	}
+
+	providers := []registry.ShimProvider{}
+	for idx, entry := range append(prioritized, others...) {
+		m, readErr := ReadFreshManifest(entry)
+
+		if readErr != nil {
+			zap.S().Warnf("skipping registry '%s': %v", entry.Alias, readErr)
+			continue
+		}
+
+		providers = append(providers, registry.ShimProvider{
+			Registry:    entry.Alias,
+			Prioritized: idx < len(prioritized),
+			Manifest:    m,
+		})
 	}
 
-	defer closer()
+	return registry.ResolveShim(shimName, providers)
+}
+
+// SetRegistryPriority will replace the priority list with the registries addressed by the names, highest
+// priority first. An empty list clears the priority list.
+func SetRegistryPriority(names []string) error {
+	return configDir.UpdateRegistryIndex(func(index *RegistryIndex) error {
+		priority := []string{}
+		inPriority := map[string]bool{}
+
+		for _, name := range names {
+			entry, err := index.Find(name)
+
+			if err != nil {
+				return err
+			}
+
+			if inPriority[entry.Alias] {
+				return fmt.Errorf("registry '%s' is in the priority list more than once", entry.Alias)
+			}
+
+			priority = append(priority, entry.Alias)
+			inPriority[entry.Alias] = true
+		}
+
+		index.Priority = priority
+
+		return nil
+	})
+}
+
+// ReadManifestFromConfigDirectory will read the manifest of the registry addressed by the name, which is
+// either its alias or its URL, from the config directory.
+func ReadManifestFromConfigDirectory(name string) (*manifest.Manifest, error) {
+	entry, err := FindRegistry(name)
 
-	m, err := manifest.ReadManifest(file)
 	if err != nil {
-		return nil, errors.Wrap(err, "error reading manifest file")
+		return nil, err
 	}
 
-	return m, nil
+	return ReadRegistryManifest(entry)
 }
 
-// ListRegistryManifestFiles will return a list of registry manifest files from the config directory.
-func ListRegistryManifestFiles() ([]string, error) {
-	registryManifests, err := configDir.ListRegistryFiles()
+// ReadRegistryManifest will read the manifest of the registry from the config directory.
+func ReadRegistryManifest(entry RegistryEntry) (*manifest.Manifest, error) {
+	if err := configDir.getLock(); err != nil {
+		return nil, errors.Wrap(err, "error getting lock while reading registry file")
+	}
+	defer configDir.unlock()
+
+	m, err := configDir.readRegistryFile(entry.ManifestFile)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "error listing the registry manifests from config")
+		return nil, errors.Wrapf(err, "error reading manifest of registry '%s'", entry.Alias)
 	}
 
-	return registryManifests, nil
+	return m, nil
 }
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameRegistry(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		newAlias         string
+		expectedAliases  []string
+		expectedPriority []string
+		expectedErr      bool
+	}{
+		{
+			name:             "by alias",
+			input:            "tools",
+			newAlias:         "renamed",
+			expectedAliases:  []string{"renamed", "tools-stable", "other"},
+			expectedPriority: []string{"other", "renamed"},
+		},
+		{
+			name:             "by URL",
+			input:            "github.com/org/tools#stable",
+			newAlias:         "renamed",
+			expectedAliases:  []string{"tools", "renamed", "other"},
+			expectedPriority: []string{"other", "tools"},
+		},
+		{name: "existing alias", input: "tools", newAlias: "other", expectedErr: true},
+		{name: "invalid alias", input: "tools", newAlias: "two words", expectedErr: true},
+		{name: "missing", input: "missing", newAlias: "renamed", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer setTestConfigDirectory(t)()
+			addTestRegistries(t)
+
+			err := RenameRegistry(test.input, test.newAlias)
+
+			if test.expectedErr {
+				assert.Error(t, err, "%s: renaming should error", test.name)
+				return
+			}
+
+			assert.NoError(t, err, "%s: renaming should not error", test.name)
+			assertTestRegistries(t, test.name, test.expectedAliases, test.expectedPriority)
+
+			m, err := ReadManifestFromConfigDirectory(test.newAlias)
+			assert.NoError(t, err, "%s: reading the renamed registry should not error", test.name)
+			assert.Equal(t, test.input, m.Source, "%s: the renamed registry should keep its manifest", test.name)
+		}()
+	}
+}
+
+func TestRemoveRegistry(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		expectedRemoved  string
+		expectedAliases  []string
+		expectedPriority []string
+		expectedErr      bool
+	}{
+		{
+			name:             "by alias",
+			input:            "tools",
+			expectedRemoved:  "tools",
+			expectedAliases:  []string{"tools-stable", "other"},
+			expectedPriority: []string{"other"},
+		},
+		{
+			name:             "by URL",
+			input:            "github.com/org/tools#stable",
+			expectedRemoved:  "tools-stable",
+			expectedAliases:  []string{"tools", "other"},
+			expectedPriority: []string{"other", "tools"},
+		},
+		{name: "URL added several times", input: "github.com/org/tools", expectedErr: true},
+		{name: "missing", input: "missing", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer setTestConfigDirectory(t)()
+			addTestRegistries(t)
+
+			removed, err := RemoveRegistry(test.input)
+
+			if test.expectedErr {
+				assert.Error(t, err, "%s: removing should error", test.name)
+				assertTestRegistries(t, test.name, []string{"tools", "tools-stable", "other"}, []string{"other", "tools"})
+				return
+			}
+
+			assert.NoError(t, err, "%s: removing should not error", test.name)
+			assert.Equal(t, test.expectedRemoved, removed.Alias, "%s: the removed registry should match", test.name)
+			assertTestRegistries(t, test.name, test.expectedAliases, test.expectedPriority)
+			assert.NoFileExists(t, filepath.Join(configDir.registryPath, removed.ManifestFile), "%s: the manifest should be removed", test.name)
+		}()
+	}
+}
+
+// addTestRegistries will add registries to the config directory, with "other" and "tools" in the priority
+// list. The manifest of each registry has the name it's addressed by in the tests as its source.
+func addTestRegistries(t *testing.T) {
+	entries := []RegistryEntry{
+		{Alias: "tools", URL: "github.com/org/tools"},
+		{Alias: "tools-stable", URL: "github.com/org/tools", Ref: "stable"},
+		{Alias: "other", URL: "github.com/org/other"},
+	}
+	sources := []string{"tools", "github.com/org/tools#stable", "other"}
+
+	for idx, entry := range entries {
+		assert.NoError(t, AddRegistry(entry, manifest.CreateManifest(sources[idx])), "should be no error adding %s", entry.Alias)
+	}
+
+	assert.NoError(t, SetRegistryPriority([]string{"other", "tools"}), "should be no error setting the priority")
+}
+
+// assertTestRegistries will assert the aliases of the registries and the priority list.
+func assertTestRegistries(t *testing.T, name string, expectedAliases, expectedPriority []string) {
+	index, err := configDir.ReadRegistryIndex()
+	assert.NoError(t, err, "%s: reading the index should not error", name)
+
+	aliases := []string{}
+	for _, entry := range index.Registries {
+		aliases = append(aliases, entry.Alias)
+	}
+
+	assert.Equal(t, expectedAliases, aliases, "%s: aliases should match", name)
+	assert.Equal(t, expectedPriority, index.Priority, "%s: priorities should match", name)
+}
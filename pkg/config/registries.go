@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/meowfaceman/conshim/pkg/utils"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// ConshimRegistryTTL is how long after being fetched a registry becomes stale, or 0 for never.
+	ConshimRegistryTTL = "conshim.registry.ttl"
+
+	// ConshimRegistryOnStale is what commands do when they read a stale registry, either warn or refresh.
+	ConshimRegistryOnStale = "conshim.registry.on-stale"
+
+	// ConshimOffline forbids fetching registries over the network.
+	ConshimOffline = "conshim.offline"
+
+	// defaultRegistryTTL is how long registries stay fresh by default.
+	defaultRegistryTTL = "168h"
+)
+
+func init() {
+	utils.Must(viper.BindEnv(ConshimRegistryTTL, "CONSHIM_REGISTRY_TTL"))
+	viper.SetDefault(ConshimRegistryTTL, defaultRegistryTTL)
+
+	registry.TTL = viper.GetDuration(ConshimRegistryTTL)
+
+	utils.Must(viper.BindEnv(ConshimRegistryOnStale, "CONSHIM_REGISTRY_ON_STALE"))
+	viper.SetDefault(ConshimRegistryOnStale, string(registry.StaleActionWarn))
+
+	utils.Must(viper.BindEnv(ConshimOffline, "CONSHIM_OFFLINE"))
+	viper.SetDefault(ConshimOffline, false)
+
+	registry.Offline = viper.GetBool(ConshimOffline)
+}
+
+// RegistryStaleAction returns what commands do when they read a stale registry.
+func RegistryStaleAction() (registry.StaleAction, error) {
+	return registry.ParseStaleAction(viper.GetString(ConshimRegistryOnStale))
+}
+
+// ReadFreshManifest will read the cached manifest of the registry, checking whether it was fetched longer
+// ago than the TTL. A stale registry is either warned about or fetched again, depending on the configured
+// stale action. If fetching it again fails, the stale manifest is used.
+func ReadFreshManifest(entry RegistryEntry) (*manifest.Manifest, error) {
+	m, err := ReadRegistryManifest(entry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Registries indexed before fetch times were recorded still have one in their manifest.
+	fetchedAt := entry.FetchedAt
+	if fetchedAt.IsZero() && m.Origin != nil {
+		fetchedAt = m.Origin.FetchedAt
+	}
+
+	if !registry.IsStale(fetchedAt, time.Now()) {
+		return m, nil
+	}
+
+	staleAction, err := RegistryStaleAction()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if staleAction == registry.StaleActionRefresh && !registry.Offline {
+		zap.S().Infof("registry '%s' is stale, updating it", entry.Alias)
+
+		refreshed, refreshErr := RefreshRegistry(entry)
+
+		if refreshErr == nil {
+			return refreshed, nil
+		}
+
+		zap.S().Warnf("error updating registry '%s', using the copy fetched %s: %v", entry.Alias, describeAge(fetchedAt), refreshErr)
+
+		return m, nil
+	}
+
+	zap.S().Warnf("registry '%s' was fetched %s, run 'conshim registry update %s' to update it", entry.Alias, describeAge(fetchedAt), entry.Alias)
+
+	return m, nil
+}
+
+// ReadFreshManifests will read the cached manifests of every registry, checking each of them for staleness
+// like ReadFreshManifest. Manifests that can't be read are skipped with a warning.
+func ReadFreshManifests() ([]*manifest.Manifest, error) {
+	entries, err := ListRegistries()
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []*manifest.Manifest{}
+	for _, entry := range entries {
+		m, readErr := ReadFreshManifest(entry)
+
+		if readErr != nil {
+			zap.S().Warnf("skipping registry '%s': %v", entry.Alias, readErr)
+			continue
+		}
+
+		manifests = append(manifests, m)
+	}
+
+	return manifests, nil
+}
+
+// RefreshRegistry will fetch the registry again and replace its cached manifest. The fetched manifest must
+// be readable by this version of conshim and declare the same source as before.
+func RefreshRegistry(entry RegistryEntry) (*manifest.Manifest, error) {
+	r, err := registry.GetRegistryWithOptions(entry.URL, entry.Options())
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := r.GetManifest()
+
+	if err := m.CheckClientVersion(); err != nil {
+		return nil, err
+	}
+
+	if err := entry.VerifySource(m); err != nil {
+		return nil, err
+	}
+
+	if err := UpdateRegistry(entry, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// describeAge will describe how long ago a registry was fetched.
+func describeAge(fetchedAt time.Time) string {
+	if fetchedAt.IsZero() {
+		return "at an unknown time"
+	}
+
+	age := time.Since(fetchedAt)
+
+	switch {
+	case age >= 48*time.Hour:
+		return fmt.Sprintf("%d days ago", int(age/(24*time.Hour)))
+	case age >= 2*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(age/time.Hour))
+	case age >= time.Minute:
+		return fmt.Sprintf("%d minutes ago", int(age/time.Minute))
+	default:
+		return "less than a minute ago"
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/meowfaceman/conshim/pkg/manifest"
 	"github.com/meowfaceman/conshim/pkg/shim"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -32,6 +34,39 @@ func AddShim(shimName, source, version string, params []string, command string)
 	return nil
 }
 
+// LoadShim will render the shim from the manifest for this host and write it to the bin directory. Existing
+// shims are only overwritten if update is true.
+func LoadShim(m *manifest.Manifest, shimName string, parameters map[string]string, update bool) error {
+	manifestShim, ok := m.GetShim(shimName)
+
+	if !ok {
+		return fmt.Errorf("no shim '%s' was found in registry %s", shimName, m.Source)
+	}
+
+	if err := m.CheckShimClientVersion(shimName); err != nil {
+		return err
+	}
+
+	// Refuse shims that can't run on this host, or pick the variant that can.
+	hostShim, err := manifestShim.ForHost()
+
+	if err != nil {
+		return err
+	}
+
+	renderedShim, err := hostShim.RenderShim(parameters)
+
+	if err != nil {
+		return err
+	}
+
+	if update {
+		return configDir.UpdateBinFile(shimName, []byte(renderedShim))
+	}
+
+	return configDir.AddBinFile(shimName, []byte(renderedShim))
+}
+
 // BinPath returns the bin path where the shims are located.
 func BinPath() string {
 	return configDir.GetBinPath()
@@ -90,3 +125,22 @@ func UpdateShim(shimName, source, version string, params []string, command strin
 
 	return nil
 }
+
+// ReadShim will read and parse a single shim from the bin directory.
+func ReadShim(shimName string) (shim.Shim, error) {
+	fullPath := configDir.GetBinFileName(shimName)
+
+	f, err := os.Open(fullPath)
+
+	if err != nil {
+		return shim.Shim{}, errors.Wrap(err, "error reading shim file")
+	}
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			zap.S().Errorf("error closing shim file '%s': %v", fullPath, closeErr)
+		}
+	}()
+
+	return shim.ParseShim(shimName, f)
+}
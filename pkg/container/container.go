@@ -0,0 +1,130 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/registry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultRuntime is the container runtime used when none is configured.
+	DefaultRuntime = "docker"
+
+	repoDigestsFormat = "{{range .RepoDigests}}{{println .}}{{end}}"
+)
+
+// Runtime is a container runtime command line tool, such as docker or podman.
+type Runtime struct {
+	// Binary is the name or path of the runtime executable.
+	Binary string
+}
+
+// NewRuntime will create a runtime that runs the given executable.
+func NewRuntime(binary string) *Runtime {
+	return &Runtime{
+		Binary: binary,
+	}
+}
+
+// Pull will pull the image with the runtime. Pulling is refused in offline mode.
+func (r *Runtime) Pull(image string) error {
+	if registry.Offline {
+		return errors.Wrapf(registry.ErrOffline, "can't pull image '%s'", image)
+	}
+
+	if _, err := r.run("pull", image); err != nil {
+		return errors.Wrapf(err, "error pulling image '%s'", image)
+	}
+
+	return nil
+}
+
+// ResolveDigest will resolve the image to the digest of its repository manifest, in the form
+// "sha256:...". The image must be present locally, so it may need to be pulled first. Only a digest from
+// the image's own repository is accepted, since a digest from another repository, such as the one a local
+// tag was copied from, can't be pulled with the image's name.
+func (r *Runtime) ResolveDigest(image string) (string, error) {
+	output, err := r.run("image", "inspect", "--format", repoDigestsFormat, image)
+
+	if err != nil {
+		return "", errors.Wrapf(err, "error inspecting image '%s'", image)
+	}
+
+	repoDigests := strings.Fields(string(output))
+
+	if len(repoDigests) == 0 {
+		return "", fmt.Errorf("image '%s' has no repository digests, it may need to be pulled or pushed", image)
+	}
+
+	repository := Repository(image)
+	for _, repoDigest := range repoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		if normalizeRepository(parts[0]) == normalizeRepository(repository) {
+			return parts[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find a digest for repository '%s' among %s", repository, strings.Join(repoDigests, ", "))
+}
+
+// run will run the runtime with the given arguments and return its standard output.
+func (r *Runtime) run(args ...string) ([]byte, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.Command(r.Binary, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, errors.Wrapf(err, "error running %s: %s", r.Binary, message)
+		}
+
+		return nil, errors.Wrapf(err, "error running %s", r.Binary)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Repository will return the repository of an image reference, stripping any tag or digest.
+func Repository(image string) string {
+	if idx := strings.Index(image, "@"); idx >= 0 {
+		image = image[:idx]
+	}
+
+	// A colon after the last slash is a tag. A colon before it is a registry port.
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		image = image[:idx]
+	}
+
+	return image
+}
+
+// normalizeRepository will expand Docker Hub shorthand so that "node", "library/node" and
+// "docker.io/library/node" compare equal.
+func normalizeRepository(repository string) string {
+	parts := strings.Split(repository, "/")
+	first := parts[0]
+
+	// The first component is a registry host if it looks like one.
+	if len(parts) == 1 || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		repository = "docker.io/" + repository
+		parts = strings.Split(repository, "/")
+	}
+
+	if parts[0] == "docker.io" && len(parts) == 2 {
+		repository = "docker.io/library/" + parts[1]
+	}
+
+	return repository
+}
@@ -0,0 +1,170 @@
+package container
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRuntimeScript is a stand-in for a container runtime that knows about a few images.
+const fakeRuntimeScript = `#!/bin/sh
+if [ "$1" = "pull" ]; then
+	exit 0
+fi
+
+if [ "$1" != "image" ] || [ "$2" != "inspect" ]; then
+	echo "unexpected arguments: $@" >&2
+	exit 2
+fi
+
+image="$5"
+case "$image" in
+	node:20)
+		echo "node@sha256:aaaa"
+		;;
+	quay.io/org/tool:1.0)
+		echo "quay.io/org/tool@sha256:bbbb"
+		echo "quay.io/mirror/tool@sha256:cccc"
+		;;
+	localhost:5000/tool)
+		echo "localhost:5000/tool@sha256:dddd"
+		;;
+	retagged:1)
+		echo "quay.io/org/original@sha256:eeee"
+		;;
+	unpushed)
+		;;
+	*)
+		echo "Error: No such image: $image" >&2
+		exit 1
+		;;
+esac
+`
+
+func TestResolveDigest(t *testing.T) {
+	tests := []struct {
+		name        string
+		image       string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "docker hub image",
+			image:    "node:20",
+			expected: "sha256:aaaa",
+		},
+		{
+			name:     "multiple repo digests",
+			image:    "quay.io/org/tool:1.0",
+			expected: "sha256:bbbb",
+		},
+		{
+			name:     "registry with port",
+			image:    "localhost:5000/tool",
+			expected: "sha256:dddd",
+		},
+		{
+			name:        "digest of another repository",
+			image:       "retagged:1",
+			expectedErr: true,
+		},
+		{
+			name:        "image without digests",
+			image:       "unpushed",
+			expectedErr: true,
+		},
+		{
+			name:        "missing image",
+			image:       "missing:1",
+			expectedErr: true,
+		},
+	}
+
+	runtime := createFakeRuntime(t, fakeRuntimeScript)
+
+	for _, test := range tests {
+		digest, err := runtime.ResolveDigest(test.image)
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+		assert.Equal(t, test.expected, digest, "%s: digests should match", test.name)
+	}
+
+	assert.NoError(t, runtime.Pull("node:20"), "pull should not error")
+}
+
+func TestRepository(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "node", expected: "node"},
+		{input: "node:20", expected: "node"},
+		{input: "node:20@sha256:aaaa", expected: "node"},
+		{input: "localhost:5000/tool", expected: "localhost:5000/tool"},
+		{input: "localhost:5000/tool:1.0", expected: "localhost:5000/tool"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Repository(test.input), "repository of %s", test.input)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     RunSpec
+		expected string
+	}{
+		{
+			name:     "image only",
+			spec:     RunSpec{Image: "jq"},
+			expected: `docker run --rm -i {{image}} "$@"`,
+		},
+		{
+			name: "all options",
+			spec: RunSpec{
+				Image:       "node:20",
+				Volumes:     []string{"./src:/src", "cache:/root/.npm:ro"},
+				Environment: []string{"NODE_ENV=production", "NPM_TOKEN", "HOME_DIR=${HOME}", "PRICE=$$5"},
+				WorkingDir:  "/src",
+				Entrypoint:  []string{"npm", "--prefix", "/src"},
+				Args:        []string{"run", "build script"},
+			},
+			expected: `docker run --rm -i -v "$PWD/src:/src" -v cache:/root/.npm:ro -e NODE_ENV=production -e NPM_TOKEN ` +
+				`-e "HOME_DIR=${HOME}" -e "PRICE=\$5" -w /src --entrypoint npm {{image}} --prefix /src run "build script" "$@"`,
+		},
+		{
+			name: "command substitutions",
+			spec: RunSpec{
+				Image:       "jq",
+				Environment: []string{"USER_ID=$(id -u)", "DIR=$HOME/.cache", "DEFAULT=${X:-$(id)}", "ARG=$1", "TRAILING=$"},
+			},
+			expected: `docker run --rm -i -e "USER_ID=\$(id -u)" -e "DIR=$HOME/.cache" -e "DEFAULT=\${X:-\$(id)}" ` +
+				`-e "ARG=\$1" -e "TRAILING=\$" {{image}} "$@"`,
+		},
+		{
+			name: "base directory",
+			spec: RunSpec{
+				Image:   "jq",
+				Volumes: []string{".:/src", "./data/:/data:ro", "../shared:/shared", "/abs:/abs", "named:/named"},
+				BaseDir: "/project/app",
+			},
+			expected: `docker run --rm -i -v /project/app:/src -v /project/app/data:/data:ro -v /project/shared:/shared ` +
+				`-v /abs:/abs -v named:/named {{image}} "$@"`,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, NewRuntime("docker").RunCommand(test.spec), "%s: commands should match", test.name)
+	}
+}
+
+// createFakeRuntime will write the script to a temporary directory and return a runtime that runs it.
+func createFakeRuntime(t *testing.T, script string) *Runtime {
+	binary := filepath.Join(t.TempDir(), "fake-runtime")
+	assert.NoError(t, ioutil.WriteFile(binary, []byte(script), 0700), "should be no error writing the fake runtime")
+
+	return NewRuntime(binary)
+}
@@ -0,0 +1,155 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LabelPrefix is the prefix of all conshim image labels.
+	LabelPrefix = "io.conshim."
+
+	// LabelName is the name of the shim. It defaults to the last component of the image repository.
+	LabelName = LabelPrefix + "name"
+
+	// LabelDescription is the description of the shim.
+	LabelDescription = LabelPrefix + "description"
+
+	// LabelParameters is a comma separated list of the shim's parameters.
+	LabelParameters = LabelPrefix + "parameters"
+
+	// LabelCommand is the full shim command. {{image}} is replaced with the image reference. If it's
+	// set, the entrypoint and env labels are ignored.
+	LabelCommand = LabelPrefix + "command"
+
+	// LabelEntrypoint overrides the image's entrypoint in the generated shim command.
+	LabelEntrypoint = LabelPrefix + "entrypoint"
+
+	// LabelEnv is a comma separated list of environment variables passed through to the container.
+	LabelEnv = LabelPrefix + "env"
+
+	// LabelTags is a comma separated list of the shim's tags.
+	LabelTags = LabelPrefix + "tags"
+
+	// LabelCategories is a comma separated list of the shim's categories.
+	LabelCategories = LabelPrefix + "categories"
+
+	labelsFormat = "{{json .Config.Labels}}"
+)
+
+// Labels will return the labels of the image. The image must be present locally.
+func (r *Runtime) Labels(image string) (map[string]string, error) {
+	output, err := r.run("image", "inspect", "--format", labelsFormat, image)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error inspecting image '%s'", image)
+	}
+
+	labels := map[string]string{}
+	if err := json.Unmarshal(output, &labels); err != nil {
+		return nil, errors.Wrapf(err, "error parsing labels of image '%s'", image)
+	}
+
+	return labels, nil
+}
+
+// ShimFromLabels will create a shim for the image from its io.conshim.* labels, returning the shim's
+// name along with it.
+func (r *Runtime) ShimFromLabels(image string, labels map[string]string) (string, shim.Shim, error) {
+	name, err := ShimNameFromLabels(image, labels)
+
+	if err != nil {
+		return "", shim.Shim{}, err
+	}
+
+	return name, r.MergeLabels(image, labels, shim.Shim{}), nil
+}
+
+// ShimNameFromLabels will return the name of the shim for the image from its io.conshim.* labels, erroring
+// if it has none.
+func ShimNameFromLabels(image string, labels map[string]string) (string, error) {
+	hasConshimLabels := false
+	for label := range labels {
+		if strings.HasPrefix(label, LabelPrefix) {
+			hasConshimLabels = true
+			break
+		}
+	}
+
+	if !hasConshimLabels {
+		return "", fmt.Errorf("image '%s' has no %s* labels", image, LabelPrefix)
+	}
+
+	name := labels[LabelName]
+	if name == "" {
+		name = path.Base(Repository(image))
+	}
+
+	// The name comes from the image, so it can't be trusted to stay inside the bin directory.
+	if err := shim.ValidateName(name); err != nil {
+		return "", errors.Wrapf(err, "image '%s' has an invalid %s label", image, LabelName)
+	}
+
+	return name, nil
+}
+
+// MergeLabels will apply the io.conshim.* labels of the image to the shim. The command and image always
+// come from the image, and the parameters come from their label or are detected from the command. The
+// description, tags and categories are only changed if the image has labels for them, and every other
+// field of the shim is left untouched. Changing the image unpins the shim's digest.
+func (r *Runtime) MergeLabels(image string, labels map[string]string, s shim.Shim) shim.Shim {
+	command := labels[LabelCommand]
+	if command == "" {
+		command = r.RunCommand(RunSpec{
+			Image:       image,
+			Environment: splitList(labels[LabelEnv]),
+			Entrypoint:  strings.Fields(labels[LabelEntrypoint]),
+		})
+	}
+
+	s.Command = command
+
+	if value, ok := labels[LabelParameters]; ok {
+		s.Parameters = splitList(value)
+	} else {
+		s.Parameters = shim.DetectParameters(command)
+	}
+
+	if value, ok := labels[LabelDescription]; ok {
+		s.Description = value
+	}
+
+	if value, ok := labels[LabelTags]; ok {
+		s.Tags = splitList(value)
+	}
+
+	if value, ok := labels[LabelCategories]; ok {
+		s.Categories = splitList(value)
+	}
+
+	// A digest pinned for a different image is meaningless.
+	if s.Image != image {
+		s.Image = image
+		s.Digest = ""
+	}
+
+	return s
+}
+
+// splitList will split a comma separated label value, dropping empty entries.
+func splitList(value string) []string {
+	list := []string{}
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,162 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLabelsRuntimeScript is a stand-in for a container runtime that reports image labels.
+const fakeLabelsRuntimeScript = `#!/bin/sh
+case "$5" in
+	example.com/tools/jq:1.6)
+		echo '{"io.conshim.description":"JSON processor","io.conshim.entrypoint":"jq","io.conshim.env":"JQ_COLORS, HOME","io.conshim.tags":"json","maintainer":"someone"}'
+		;;
+	example.com/tools/aws:2)
+		echo '{"io.conshim.name":"aws","io.conshim.command":"docker run --rm -v {{config}}:/root/.aws {{image}} \"$@\""}'
+		;;
+	example.com/tools/evil:1)
+		echo '{"io.conshim.name":"../../.bashrc","io.conshim.command":"evil"}'
+		;;
+	plain:latest)
+		echo 'null'
+		;;
+	*)
+		echo "Error: No such image: $5" >&2
+		exit 1
+		;;
+esac
+`
+
+func TestShimFromLabels(t *testing.T) {
+	tests := []struct {
+		name         string
+		image        string
+		expectedName string
+		expected     shim.Shim
+		expectedErr  bool
+	}{
+		{
+			name:         "generated command",
+			image:        "example.com/tools/jq:1.6",
+			expectedName: "jq",
+			expected: shim.Shim{
+				Description: "JSON processor",
+				Parameters:  []string{},
+				Command:     `docker run --rm -i -e JQ_COLORS -e HOME --entrypoint jq {{image}} "$@"`,
+				Tags:        []string{"json"},
+				Image:       "example.com/tools/jq:1.6",
+			},
+		},
+		{
+			name:         "explicit command",
+			image:        "example.com/tools/aws:2",
+			expectedName: "aws",
+			expected: shim.Shim{
+				Parameters: []string{"config"},
+				Command:    `docker run --rm -v {{config}}:/root/.aws {{image}} "$@"`,
+				Image:      "example.com/tools/aws:2",
+			},
+		},
+		{
+			name:        "name outside the bin directory",
+			image:       "example.com/tools/evil:1",
+			expectedErr: true,
+		},
+		{
+			name:        "no conshim labels",
+			image:       "plain:latest",
+			expectedErr: true,
+		},
+		{
+			name:        "missing image",
+			image:       "missing:latest",
+			expectedErr: true,
+		},
+	}
+
+	fakeRuntime := createFakeRuntime(t, fakeLabelsRuntimeScript)
+
+	// Inspect with the fake runtime, but generate commands for docker so that they're predictable.
+	dockerRuntime := NewRuntime("docker")
+
+	for _, test := range tests {
+		name, s, err := func() (string, shim.Shim, error) {
+			labels, err := fakeRuntime.Labels(test.image)
+
+			if err != nil {
+				return "", shim.Shim{}, err
+			}
+
+			return dockerRuntime.ShimFromLabels(test.image, labels)
+		}()
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+		if !test.expectedErr {
+			assert.Equal(t, test.expectedName, name, "%s: names should match", test.name)
+			assert.Equal(t, test.expected, s, "%s: shims should match", test.name)
+		}
+	}
+}
+
+func TestMergeLabels(t *testing.T) {
+	existing := shim.Shim{
+		Version:           "3",
+		Description:       "Hand written description",
+		Parameters:        []string{"old"},
+		Command:           "old-command",
+		Platforms:         []string{"linux"},
+		Tags:              []string{"old-tag"},
+		Image:             "example.com/tools/jq:1.6",
+		Digest:            "sha256:aaaa",
+		MinConshimVersion: "1.2.0",
+		Upstream:          &shim.Upstream{Source: "github.com/some/registry", Name: "jq", Version: "1", ManifestVersion: "1.0.0"},
+	}
+
+	tests := []struct {
+		name     string
+		image    string
+		labels   map[string]string
+		expected shim.Shim
+	}{
+		{
+			name:   "refreshed image",
+			image:  "example.com/tools/jq:1.6",
+			labels: map[string]string{LabelCommand: `docker run {{image}} "$@"`, LabelTags: "json, cli"},
+			expected: shim.Shim{
+				Version:           "3",
+				Description:       "Hand written description",
+				Parameters:        []string{},
+				Command:           `docker run {{image}} "$@"`,
+				Platforms:         []string{"linux"},
+				Tags:              []string{"json", "cli"},
+				Image:             "example.com/tools/jq:1.6",
+				Digest:            "sha256:aaaa",
+				MinConshimVersion: "1.2.0",
+				Upstream:          existing.Upstream,
+			},
+		},
+		{
+			name:   "new image",
+			image:  "example.com/tools/jq:1.7",
+			labels: map[string]string{LabelCommand: `docker run {{image}} "$@"`, LabelDescription: "JSON processor"},
+			expected: shim.Shim{
+				Version:           "3",
+				Description:       "JSON processor",
+				Parameters:        []string{},
+				Command:           `docker run {{image}} "$@"`,
+				Platforms:         []string{"linux"},
+				Tags:              []string{"old-tag"},
+				Image:             "example.com/tools/jq:1.7",
+				MinConshimVersion: "1.2.0",
+				Upstream:          existing.Upstream,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		merged := NewRuntime("docker").MergeLabels(test.image, test.labels, existing)
+		assert.Equal(t, test.expected, merged, "%s: shims should match", test.name)
+	}
+}
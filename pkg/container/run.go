@@ -0,0 +1,122 @@
+package container
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+)
+
+var (
+	safeShellWordRegex = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+	// dollarRegex matches the dollar signs in a word, along with the variable reference each one starts,
+	// if any.
+	dollarRegex = regexp.MustCompile(`\$\{[A-Za-z_]\w*\}|\$[A-Za-z_]\w*|\$\$?`)
+)
+
+// RunSpec describes how a shim should run a container.
+type RunSpec struct {
+	// Image is the image to run.
+	Image string
+
+	// Volumes are volume mounts in the form "source:target[:options]". Sources starting with "./" or
+	// "../" are relative to BaseDir.
+	Volumes []string
+
+	// BaseDir is the directory relative volume sources are resolved against. If it's empty, they're
+	// relative to the directory the shim is run from.
+	BaseDir string
+
+	// Environment are environment variables in the form "KEY=VALUE", or "KEY" to pass the variable
+	// through from the shim's environment.
+	Environment []string
+
+	// WorkingDir is the working directory inside the container.
+	WorkingDir string
+
+	// Entrypoint overrides the image's entrypoint. The first element is the executable and the rest are
+	// passed as leading arguments.
+	Entrypoint []string
+
+	// Args are arguments passed to the container before the shim's own arguments.
+	Args []string
+}
+
+// RunCommand will build a shim command that runs the spec with the runtime. The image is referenced
+// through the {{image}} placeholder so that it can be pinned to a digest.
+func (r *Runtime) RunCommand(spec RunSpec) string {
+	words := []string{shellQuote(r.Binary), "run", "--rm", "-i"}
+
+	for _, volume := range spec.Volumes {
+		words = append(words, "-v", shellQuote(expandRelativePath(volume, spec.BaseDir)))
+	}
+
+	for _, env := range spec.Environment {
+		words = append(words, "-e", shellQuote(env))
+	}
+
+	if spec.WorkingDir != "" {
+		words = append(words, "-w", shellQuote(spec.WorkingDir))
+	}
+
+	args := spec.Args
+	if len(spec.Entrypoint) > 0 {
+		words = append(words, "--entrypoint", shellQuote(spec.Entrypoint[0]))
+		args = append(append([]string{}, spec.Entrypoint[1:]...), args...)
+	}
+
+	words = append(words, shim.ImagePlaceholder)
+
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+
+	words = append(words, `"$@"`)
+
+	return strings.Join(words, " ")
+}
+
+// expandRelativePath will resolve a relative volume source against the base directory, or replace its
+// leading "." with the directory the shim is run from if there's no base directory.
+func expandRelativePath(volume, baseDir string) string {
+	source, rest := volume, ""
+	if idx := strings.Index(volume, ":"); idx >= 0 {
+		source, rest = volume[:idx], volume[idx:]
+	}
+
+	isRelative := source == "." || source == ".." || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+
+	switch {
+	case !isRelative:
+		return volume
+	case baseDir != "":
+		return filepath.Join(baseDir, source) + rest
+	case source == "." || strings.HasPrefix(source, "./"):
+		return "$PWD" + volume[1:]
+	default:
+		return volume
+	}
+}
+
+// shellQuote will quote the word for bash if necessary. Words are double quoted so that variable
+// references such as ${HOME} and $HOME are expanded when the shim runs, like compose interpolation. Every
+// other dollar sign is escaped, so that command substitutions such as $(...) aren't run, and a "$$"
+// escapes a literal dollar sign.
+func shellQuote(word string) string {
+	if safeShellWordRegex.MatchString(word) {
+		return word
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(word)
+	escaped = dollarRegex.ReplaceAllStringFunc(escaped, func(match string) string {
+		if match == "$" || match == "$$" {
+			return `\$`
+		}
+
+		return match
+	})
+
+	return `"` + escaped + `"`
+}
@@ -0,0 +1,159 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultEditor is the editor used if neither $VISUAL nor $EDITOR are set.
+	DefaultEditor = "vi"
+
+	errorCommentPrefix = "# error: "
+	fixErrorsComment   = "# Fix the errors above, or empty the file to abort."
+)
+
+var (
+	// ErrUnchanged is returned when the user exits the editor without changing anything.
+	ErrUnchanged = errors.New("no changes were made")
+
+	// ErrEmpty is returned when the user exits the editor with an empty file.
+	ErrEmpty = errors.New("the edited file was empty")
+)
+
+// Validator checks the edited contents. If it returns an error, the editor is re-opened with the error.
+type Validator func(contents []byte) error
+
+// Editor opens files in the user's text editor.
+type Editor struct {
+	// Command is the editor command. It's run through the shell so that it may contain arguments.
+	Command string
+}
+
+// New will create an editor from $VISUAL or $EDITOR, falling back to DefaultEditor.
+func New() *Editor {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if command := os.Getenv(env); command != "" {
+			return &Editor{Command: command}
+		}
+	}
+
+	return &Editor{Command: DefaultEditor}
+}
+
+// Edit will open the initial contents in the editor until they pass validation, and return the edited
+// contents. The file name pattern is used for the temporary file so that editors can pick syntax
+// highlighting, such as "*.yaml". Validation errors are shown to the user as comments at the top of the
+// file. If the contents are left unchanged or emptied, editing is aborted.
+func (e *Editor) Edit(initial []byte, pattern string, validate Validator) ([]byte, error) {
+	tmpFile, err := ioutil.TempFile("", pattern)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating temporary file for editing")
+	}
+
+	tmpFileName := tmpFile.Name()
+
+	defer func() {
+		if removeErr := os.Remove(tmpFileName); removeErr != nil {
+			zap.S().Errorf("error removing temporary edit file: %v", removeErr)
+		}
+	}()
+
+	if err := tmpFile.Close(); err != nil {
+		return nil, errors.Wrap(err, "error closing temporary file for editing")
+	}
+
+	contents := initial
+	for {
+		if err := ioutil.WriteFile(tmpFileName, contents, 0600); err != nil {
+			return nil, errors.Wrap(err, "error writing temporary file for editing")
+		}
+
+		if err := e.run(tmpFileName); err != nil {
+			return nil, err
+		}
+
+		edited, err := ioutil.ReadFile(tmpFileName)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading edited file")
+		}
+
+		edited = stripErrorComments(edited)
+
+		if len(bytes.TrimSpace(edited)) == 0 {
+			return nil, ErrEmpty
+		}
+
+		if bytes.Equal(edited, initial) {
+			return nil, ErrUnchanged
+		}
+
+		validationErr := validate(edited)
+
+		if validationErr == nil {
+			return edited, nil
+		}
+
+		contents = append(errorComments(validationErr), edited...)
+	}
+}
+
+// run will run the editor on the file, attached to the terminal.
+func (e *Editor) run(fileName string) error {
+	cmd := exec.Command("sh", "-c", e.Command+` "$1"`, "editor", fileName)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "error running editor '%s'", e.Command)
+	}
+
+	return nil
+}
+
+// errorComments will render the error as comment lines to put at the top of the edited file.
+func errorComments(err error) []byte {
+	builder := strings.Builder{}
+
+	for _, line := range strings.Split(strings.TrimSpace(err.Error()), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			builder.WriteString(errorCommentPrefix + line + "\n")
+		}
+	}
+
+	builder.WriteString(fixErrorsComment + "\n")
+
+	return []byte(builder.String())
+}
+
+// stripErrorComments will remove the error comments added by errorComments from the top of the file.
+func stripErrorComments(contents []byte) []byte {
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	offset := 0
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, errorCommentPrefix) && line != fixErrorsComment {
+			break
+		}
+
+		offset += len(line) + 1
+	}
+
+	if offset > len(contents) {
+		return []byte{}
+	}
+
+	return contents[offset:]
+}
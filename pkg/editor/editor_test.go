@@ -0,0 +1,94 @@
+package editor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		name        string
+		edits       []string
+		expected    string
+		expectedErr error
+		validations int
+	}{
+		{
+			name:        "valid edit",
+			edits:       []string{"value: 2\n"},
+			expected:    "value: 2\n",
+			validations: 1,
+		},
+		{
+			name:        "invalid edit is re-opened",
+			edits:       []string{"value: bad\n", "value: 3\n"},
+			expected:    "value: 3\n",
+			validations: 2,
+		},
+		{
+			name:        "unchanged",
+			edits:       []string{"value: 1\n"},
+			expectedErr: ErrUnchanged,
+		},
+		{
+			name:        "emptied after error",
+			edits:       []string{"value: bad\n", ""},
+			expectedErr: ErrEmpty,
+			validations: 1,
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		e := createFakeEditor(t, dir, test.edits)
+
+		validations := 0
+		seenErrorComment := false
+		edited, err := e.Edit([]byte("value: 1\n"), "*.yaml", func(contents []byte) error {
+			validations++
+
+			if strings.Contains(string(contents), "bad") {
+				return fmt.Errorf("value must be a number")
+			}
+
+			return nil
+		})
+
+		// The fake editor records what it was given on each run.
+		for i := 1; i < len(test.edits); i++ {
+			given, readErr := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("given-%d", i+1)))
+			assert.NoError(t, readErr, "%s: should be no error reading the editor input", test.name)
+			seenErrorComment = seenErrorComment || strings.HasPrefix(string(given), "# error: value must be a number\n")
+		}
+
+		assert.Equal(t, test.expectedErr, err, "%s: errors should match", test.name)
+		assert.Equal(t, test.expected, string(edited), "%s: edited contents should match", test.name)
+		assert.Equal(t, test.validations, validations, "%s: number of validations should match", test.name)
+		assert.Equal(t, len(test.edits) > 1, seenErrorComment, "%s: errors should be shown when re-opening", test.name)
+	}
+}
+
+// createFakeEditor will create an editor that replaces the file with each of the edits in turn.
+func createFakeEditor(t *testing.T, dir string, edits []string) *Editor {
+	for i, edit := range edits {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("edit-%d", i+1)), []byte(edit), 0600), "should be no error writing edit")
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+count=$(cat %[1]s/count 2>/dev/null || echo 0)
+count=$((count + 1))
+echo $count > %[1]s/count
+cp "$1" %[1]s/given-$count
+cp %[1]s/edit-$count "$1"
+`, dir)
+
+	editorPath := filepath.Join(dir, "fake-editor")
+	assert.NoError(t, ioutil.WriteFile(editorPath, []byte(script), 0700), "should be no error writing the fake editor")
+
+	return &Editor{Command: editorPath}
+}
@@ -0,0 +1,157 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meowfaceman/conshim/pkg/semver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// InitialVersion is the version given to newly created manifests.
+	InitialVersion = "1.0.0"
+
+	// ActionAdd is the changelog action for adding a shim.
+	ActionAdd = "add"
+
+	// ActionUpdate is the changelog action for updating a shim.
+	ActionUpdate = "update"
+
+	// ActionRemove is the changelog action for removing a shim.
+	ActionRemove = "remove"
+
+	// ActionPin is the changelog action for pinning a shim's image digest.
+	ActionPin = "pin"
+)
+
+var (
+	// now is the clock used for changelog timestamps. It's replaced in tests.
+	now = time.Now
+)
+
+// ChangelogEntry is a record of a change made to the manifest.
+type ChangelogEntry struct {
+	// Version is the manifest version that the change was released in.
+	Version string `json:"version" yaml:"version"`
+
+	// Shim is the name of the shim that was changed.
+	Shim string `json:"shim" yaml:"shim"`
+
+	// Action is the kind of change that was made to the shim.
+	Action string `json:"action" yaml:"action"`
+
+	// Message is a description of the change.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// Timestamp is the time the change was made.
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// String will return a string representation of the changelog entry.
+func (c ChangelogEntry) String() string {
+	entry := fmt.Sprintf("%s %s %s %s", c.Version, c.Timestamp.Format(time.RFC3339), c.Action, c.Shim)
+
+	if c.Message != "" {
+		entry += ": " + c.Message
+	}
+
+	return entry
+}
+
+// BumpVersion will increment the manifest version. A manifest without a version is treated as 0.0.0.
+func (m *Manifest) BumpVersion(bump semver.Bump) error {
+	current := semver.Version{}
+
+	if m.Version != "" {
+		var err error
+		current, err = semver.Parse(m.Version)
+
+		if err != nil {
+			return errors.Wrap(err, "error parsing current manifest version")
+		}
+	}
+
+	bumped, err := current.Bump(bump)
+
+	if err != nil {
+		return errors.Wrap(err, "error bumping manifest version")
+	}
+
+	m.Version = bumped.String()
+
+	return nil
+}
+
+// SetVersion will explicitly set the manifest version. The new version must be higher than the current one.
+func (m *Manifest) SetVersion(version string) error {
+	newVersion, err := semver.Parse(version)
+
+	if err != nil {
+		return errors.Wrap(err, "error parsing new manifest version")
+	}
+
+	if m.Version != "" {
+		current, err := semver.Parse(m.Version)
+
+		if err != nil {
+			return errors.Wrap(err, "error parsing current manifest version")
+		}
+
+		if !current.LessThan(newVersion) {
+			return fmt.Errorf("new manifest version %s must be higher than the current version %s", newVersion, current)
+		}
+	}
+
+	m.Version = newVersion.String()
+
+	return nil
+}
+
+// AddChangelogEntry will record a change to a shim against the current manifest version.
+func (m *Manifest) AddChangelogEntry(shimName, action, message string) {
+	m.Changelog = append(m.Changelog, ChangelogEntry{
+		Version:   m.Version,
+		Shim:      shimName,
+		Action:    action,
+		Message:   message,
+		Timestamp: now().UTC(),
+	})
+}
+
+// AddChangelogEntries will record changes to several shims against the current manifest version. The
+// changes map shim names to actions, and are recorded in order of shim name.
+func (m *Manifest) AddChangelogEntries(changes map[string]string, message string) {
+	for _, shimName := range sortedChanges(changes) {
+		m.AddChangelogEntry(shimName, changes[shimName], message)
+	}
+}
+
+// ChangelogToString will return a string representation of the changelog, optionally limited to a single shim.
+func (m *Manifest) ChangelogToString(shimName string) string {
+	entries := []string{}
+
+	for _, entry := range m.Changelog {
+		if shimName != "" && entry.Shim != shimName {
+			continue
+		}
+
+		entries = append(entries, entry.String()+"\n")
+	}
+
+	return strings.Join(entries, "")
+}
+
+// sortedChanges will return the shim names of the changes in sorted order.
+func sortedChanges(changes map[string]string) []string {
+	shimNames := make([]string, 0, len(changes))
+	for shimName := range changes {
+		shimNames = append(shimNames, shimName)
+	}
+
+	sort.Strings(shimNames)
+
+	return shimNames
+}
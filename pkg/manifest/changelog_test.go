@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meowfaceman/conshim/pkg/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		bump        semver.Bump
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "bump patch",
+			version:  "1.0.0",
+			bump:     semver.BumpPatch,
+			expected: "1.0.1",
+		},
+		{
+			name:     "bump minor",
+			version:  "1.0.3",
+			bump:     semver.BumpMinor,
+			expected: "1.1.0",
+		},
+		{
+			name:     "bump empty version",
+			version:  "",
+			bump:     semver.BumpPatch,
+			expected: "0.0.1",
+		},
+		{
+			name:        "bump invalid version",
+			version:     "not-a-version",
+			bump:        semver.BumpPatch,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		m := CreateManifest(testSourceName)
+		m.Version = test.version
+
+		err := m.BumpVersion(test.bump)
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+		if !test.expectedErr {
+			assert.Equal(t, test.expected, m.Version, "%s: versions should match", test.name)
+		}
+	}
+}
+
+func TestSetVersion(t *testing.T) {
+	m := CreateManifest(testSourceName)
+
+	assert.Error(t, m.SetVersion(InitialVersion), "setting the same version should error")
+	assert.Error(t, m.SetVersion("0.9.0"), "setting a lower version should error")
+	assert.Error(t, m.SetVersion("bogus"), "setting an invalid version should error")
+	assert.NoError(t, m.SetVersion("v2.0.0"), "setting a higher version should not error")
+	assert.Equal(t, "2.0.0", m.Version, "version should be normalized")
+
+	m.Version = "bogus"
+	assert.Error(t, m.SetVersion("3.0.0"), "setting a version over an invalid current version should error")
+}
+
+func TestChangelog(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time {
+		return time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	m := CreateManifest(testSourceName)
+
+	assert.NoError(t, m.BumpVersion(semver.BumpPatch), "should not error")
+	m.AddChangelogEntry("jq", ActionAdd, "initial jq shim")
+
+	assert.NoError(t, m.BumpVersion(semver.BumpMinor), "should not error")
+	m.AddChangelogEntry("yq", ActionAdd, "")
+
+	assert.Equal(t, "1.0.1 2021-05-01T12:00:00Z add jq: initial jq shim\n1.1.0 2021-05-01T12:00:00Z add yq\n",
+		m.ChangelogToString(""), "full changelog should match")
+	assert.Equal(t, "1.1.0 2021-05-01T12:00:00Z add yq\n", m.ChangelogToString("yq"), "filtered changelog should match")
+}
@@ -0,0 +1,83 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/semver"
+	"github.com/meowfaceman/conshim/pkg/version"
+	"github.com/pkg/errors"
+)
+
+// ClientTooOldError is returned when a manifest or shim needs a newer version of conshim.
+type ClientTooOldError struct {
+	// Shim is the name of the shim that needs a newer version, or empty if it's the whole manifest.
+	Shim string
+
+	// Required is the minimum version of conshim needed.
+	Required string
+
+	// Client is the version of this conshim.
+	Client string
+}
+
+// Error will return the error message, which tells the user to upgrade.
+func (e *ClientTooOldError) Error() string {
+	subject := "this manifest"
+	if e.Shim != "" {
+		subject = fmt.Sprintf("shim '%s'", e.Shim)
+	}
+
+	return fmt.Sprintf("%s requires conshim %s or newer, but this is conshim %s; please upgrade conshim", subject, e.Required, e.Client)
+}
+
+// CheckClientVersion will return a ClientTooOldError if the manifest needs a newer version of conshim
+// than this one.
+func (m *Manifest) CheckClientVersion() error {
+	return checkClientVersion("", m.MinConshimVersion)
+}
+
+// CheckShimClientVersion will return a ClientTooOldError if the manifest or the shim needs a newer version
+// of conshim than this one.
+func (m *Manifest) CheckShimClientVersion(shimName string) error {
+	if err := m.CheckClientVersion(); err != nil {
+		return err
+	}
+
+	return checkClientVersion(shimName, m.Shims[shimName].MinConshimVersion)
+}
+
+// ShimsRequiringNewerClient will return the sorted names of the shims that need a newer version of conshim
+// than this one.
+func (m *Manifest) ShimsRequiringNewerClient() []string {
+	names := []string{}
+
+	for _, shimName := range shimNames(m) {
+		if checkClientVersion(shimName, m.Shims[shimName].MinConshimVersion) != nil {
+			names = append(names, shimName)
+		}
+	}
+
+	return names
+}
+
+// checkClientVersion will check the minimum version against the version of this conshim. Development
+// builds satisfy every minimum version.
+func checkClientVersion(shimName, minimum string) error {
+	if minimum == "" {
+		return nil
+	}
+
+	required, err := semver.Parse(minimum)
+
+	if err != nil {
+		return errors.Wrap(err, "error parsing minimum conshim version")
+	}
+
+	client, ok := version.Client()
+
+	if !ok || !client.LessThan(required) {
+		return nil
+	}
+
+	return &ClientTooOldError{Shim: shimName, Required: required.String(), Client: client.String()}
+}
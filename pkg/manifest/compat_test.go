@@ -0,0 +1,85 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/meowfaceman/conshim/pkg/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckClientVersion(t *testing.T) {
+	defer func(original string) {
+		version.Version = original
+	}(version.Version)
+
+	tests := []struct {
+		name            string
+		client          string
+		manifestMinimum string
+		shimMinimum     string
+		expectedErr     bool
+		expectedShim    string
+	}{
+		{
+			name:   "no minimums",
+			client: "1.0.0",
+		},
+		{
+			name:            "new enough client",
+			client:          "v1.2.0",
+			manifestMinimum: "1.2",
+			shimMinimum:     "1.1.5",
+		},
+		{
+			name:            "client older than the manifest minimum",
+			client:          "1.1.0",
+			manifestMinimum: "1.2.0",
+			expectedErr:     true,
+		},
+		{
+			name:         "client older than the shim minimum",
+			client:       "1.1.0",
+			shimMinimum:  "1.1.1",
+			expectedErr:  true,
+			expectedShim: "jq",
+		},
+		{
+			name:            "development client",
+			client:          version.Development,
+			manifestMinimum: "99.0.0",
+			shimMinimum:     "99.0.0",
+		},
+	}
+
+	for _, test := range tests {
+		version.Version = test.client
+
+		m := CreateManifest(testSourceName)
+		m.MinConshimVersion = test.manifestMinimum
+		m.Shims["jq"] = shim.Shim{Command: "docker run jq", MinConshimVersion: test.shimMinimum}
+		m.Shims["yq"] = shim.Shim{Command: "docker run yq"}
+
+		err := m.CheckShimClientVersion("jq")
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+
+		tooOld := &ClientTooOldError{}
+		if test.expectedErr && assert.True(t, errors.As(err, &tooOld), "%s: error should be a client too old error", test.name) {
+			assert.Equal(t, test.expectedShim, tooOld.Shim, "%s: shims should match", test.name)
+			assert.Contains(t, err.Error(), "please upgrade conshim", "%s: error should ask to upgrade", test.name)
+		}
+
+		assert.Equal(t, test.manifestMinimum != "" && test.expectedErr, m.CheckClientVersion() != nil, "%s: manifest error states should equal", test.name)
+		if test.manifestMinimum == "" {
+			assert.NoError(t, m.CheckShimClientVersion("yq"), "%s: shims without minimums should only be limited by the manifest", test.name)
+		}
+
+		expectedShims := []string{}
+		if test.expectedShim != "" {
+			expectedShims = append(expectedShims, test.expectedShim)
+		}
+
+		assert.Equal(t, expectedShims, m.ShimsRequiringNewerClient(), "%s: shims requiring a newer client should match", test.name)
+	}
+}
@@ -0,0 +1,117 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DigestResolver resolves an image reference to a digest in the form "sha256:...".
+type DigestResolver func(image string) (string, error)
+
+// DigestDrift is a shim whose pinned digest differs from the digest its image currently resolves to.
+type DigestDrift struct {
+	// Shim is the name of the shim.
+	Shim string
+
+	// Image is the image used by the shim.
+	Image string
+
+	// Pinned is the digest pinned in the manifest. It's empty if the shim isn't pinned.
+	Pinned string
+
+	// Current is the digest the image currently resolves to.
+	Current string
+}
+
+// String will return a string representation of the drift.
+func (d DigestDrift) String() string {
+	if d.Pinned == "" {
+		return fmt.Sprintf("%s: %s is not pinned and resolves to %s", d.Shim, d.Image, d.Current)
+	}
+
+	return fmt.Sprintf("%s: %s is pinned to %s but resolves to %s", d.Shim, d.Image, d.Pinned, d.Current)
+}
+
+// Images will return the sorted, unique list of images used by shims in the manifest.
+func (m *Manifest) Images() []string {
+	seen := map[string]bool{}
+	images := []string{}
+
+	for _, s := range m.Shims {
+		if s.Image != "" && !seen[s.Image] {
+			seen[s.Image] = true
+			images = append(images, s.Image)
+		}
+	}
+
+	sort.Strings(images)
+
+	return images
+}
+
+// PinDigests will resolve every image in the manifest and pin the shims using it to the resulting digest.
+// The names of the shims whose digests changed are returned, along with the names of the shims that have
+// no image and so can't be pinned.
+func (m *Manifest) PinDigests(resolve DigestResolver) ([]string, []string, error) {
+	drifts, unpinnable, err := m.CheckDigests(resolve)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pinned := []string{}
+	for _, drift := range drifts {
+		s := m.Shims[drift.Shim]
+		s.Digest = drift.Current
+		m.Shims[drift.Shim] = s
+
+		pinned = append(pinned, drift.Shim)
+	}
+
+	return pinned, unpinnable, nil
+}
+
+// CheckDigests will resolve every image in the manifest and report the shims whose pinned digest doesn't
+// match, including shims that haven't been pinned. The names of the shims that have no image, and so can't
+// be pinned, are returned separately and don't count as drift. Both are sorted by shim name.
+func (m *Manifest) CheckDigests(resolve DigestResolver) ([]DigestDrift, []string, error) {
+	digests := map[string]string{}
+
+	for _, image := range m.Images() {
+		digest, err := resolve(image)
+
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error resolving digest for image '%s'", image)
+		}
+
+		digests[image] = digest
+	}
+
+	drifts := []DigestDrift{}
+	unpinnable := []string{}
+	for shimName, s := range m.Shims {
+		if s.Image == "" {
+			unpinnable = append(unpinnable, shimName)
+			continue
+		}
+
+		if current := digests[s.Image]; current != s.Digest {
+			drifts = append(drifts, DigestDrift{
+				Shim:    shimName,
+				Image:   s.Image,
+				Pinned:  s.Digest,
+				Current: current,
+			})
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Shim < drifts[j].Shim
+	})
+
+	sort.Strings(unpinnable)
+
+	return drifts, unpinnable, nil
+}
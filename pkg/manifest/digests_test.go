@@ -0,0 +1,57 @@
+package manifest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinDigests(t *testing.T) {
+	digests := map[string]string{
+		"node:20": "sha256:aaaa",
+		"jq:1.6":  "sha256:bbbb",
+	}
+	resolveCount := 0
+	resolve := func(image string) (string, error) {
+		resolveCount++
+
+		if digest, ok := digests[image]; ok {
+			return digest, nil
+		}
+
+		return "", fmt.Errorf("no such image '%s'", image)
+	}
+
+	m := CreateManifest(testSourceName)
+	assert.NoError(t, m.AddShim("node", shim.Shim{Command: "docker run {{image}} node", Image: "node:20"}), "should not error")
+	assert.NoError(t, m.AddShim("npm", shim.Shim{Command: "docker run {{image}} npm", Image: "node:20"}), "should not error")
+	assert.NoError(t, m.AddShim("jq", shim.Shim{Command: "docker run {{image}}", Image: "jq:1.6", Digest: "sha256:bbbb"}), "should not error")
+	assert.NoError(t, m.AddShim("local", shim.Shim{Command: "echo local"}), "should not error")
+
+	drifts, unpinnable, err := m.CheckDigests(resolve)
+	assert.NoError(t, err, "check should not error")
+	assert.Equal(t, []DigestDrift{
+		{Shim: "node", Image: "node:20", Current: "sha256:aaaa"},
+		{Shim: "npm", Image: "node:20", Current: "sha256:aaaa"},
+	}, drifts, "unpinned shims should drift")
+	assert.Equal(t, []string{"local"}, unpinnable, "shims without an image should be reported separately")
+	assert.Equal(t, 2, resolveCount, "each image should only be resolved once")
+
+	pinned, unpinnable, err := m.PinDigests(resolve)
+	assert.NoError(t, err, "pin should not error")
+	assert.Equal(t, []string{"node", "npm"}, pinned, "unpinned shims should be pinned")
+	assert.Equal(t, []string{"local"}, unpinnable, "shims without an image should be reported")
+	assert.Equal(t, "sha256:aaaa", m.Shims["npm"].Digest, "digest should be recorded")
+
+	digests["node:20"] = "sha256:eeee"
+	drifts, _, err = m.CheckDigests(resolve)
+	assert.NoError(t, err, "check should not error")
+	assert.Len(t, drifts, 2, "retagged image should drift")
+	assert.Equal(t, "sha256:aaaa", drifts[0].Pinned, "drift should report the pinned digest")
+
+	assert.NoError(t, m.AddShim("broken", shim.Shim{Command: "docker run {{image}}", Image: "missing"}), "should not error")
+	_, _, err = m.PinDigests(resolve)
+	assert.Error(t, err, "unresolvable image should error")
+}
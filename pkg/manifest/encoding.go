@@ -0,0 +1,167 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Encoding is the format a manifest is serialized in.
+type Encoding string
+
+const (
+	// EncodingBrotli is brotli compressed JSON in an envelope.
+	EncodingBrotli Encoding = "brotli"
+
+	// EncodingZstd is zstd compressed JSON in an envelope.
+	EncodingZstd Encoding = "zstd"
+
+	// EncodingGzip is gzip compressed JSON in an envelope.
+	EncodingGzip Encoding = "gzip"
+
+	// EncodingJSON is uncompressed JSON in an envelope.
+	EncodingJSON Encoding = "json"
+
+	// EncodingLegacyBrotli is brotli compressed JSON without an envelope, as written by older versions
+	// of conshim.
+	EncodingLegacyBrotli Encoding = "legacy-brotli"
+
+	// DefaultEncoding is the encoding used for new manifests. It has no envelope, so that the manifests
+	// can still be read by older versions of conshim. Enveloped encodings have to be asked for.
+	DefaultEncoding = EncodingLegacyBrotli
+)
+
+var (
+	// envelopeMagic starts every enveloped manifest. It's followed by a single format byte.
+	envelopeMagic = []byte("CSHM")
+
+	formatBytes = map[Encoding]byte{
+		EncodingBrotli: 1,
+		EncodingZstd:   2,
+		EncodingGzip:   3,
+		EncodingJSON:   4,
+	}
+
+	// Encodings is the list of encodings that manifests can be written in.
+	Encodings = []Encoding{EncodingBrotli, EncodingZstd, EncodingGzip, EncodingJSON, EncodingLegacyBrotli}
+)
+
+// ParseEncoding will parse the name of an encoding.
+func ParseEncoding(name string) (Encoding, error) {
+	names := []string{}
+	for _, encoding := range Encodings {
+		if string(encoding) == name {
+			return encoding, nil
+		}
+
+		names = append(names, string(encoding))
+	}
+
+	return "", fmt.Errorf("unknown manifest encoding '%s', expected one of %s", name, strings.Join(names, ", "))
+}
+
+// detectEncoding will read the envelope from the reader, if there is one, and return the encoding along
+// with a reader positioned at the start of the encoded manifest. Headerless data is assumed to be
+// legacy brotli.
+func detectEncoding(src io.Reader) (Encoding, io.Reader, error) {
+	bReader := bufio.NewReader(src)
+	envelopeLength := len(envelopeMagic) + 1
+
+	header, err := bReader.Peek(envelopeLength)
+
+	if err != nil && err != io.EOF {
+		return "", nil, errors.Wrap(err, "error reading manifest envelope")
+	}
+
+	if len(header) < envelopeLength || !bytes.Equal(header[:len(envelopeMagic)], envelopeMagic) {
+		return EncodingLegacyBrotli, bReader, nil
+	}
+
+	formatByte := header[len(envelopeMagic)]
+	for encoding, b := range formatBytes {
+		if b == formatByte {
+			if _, err := bReader.Discard(envelopeLength); err != nil {
+				return "", nil, errors.Wrap(err, "error reading manifest envelope")
+			}
+
+			return encoding, bReader, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("unknown manifest format byte %d, the manifest may need a newer version of conshim", formatByte)
+}
+
+// newDecoder will wrap the reader with a decompressor for the given encoding.
+func newDecoder(encoding Encoding, src io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingBrotli, EncodingLegacyBrotli:
+		return ioutil.NopCloser(brotli.NewReader(src)), nil
+	case EncodingZstd:
+		decoder, err := zstd.NewReader(src, zstd.WithDecoderConcurrency(1))
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating zstd reader")
+		}
+
+		return decoder.IOReadCloser(), nil
+	case EncodingGzip:
+		gReader, err := gzip.NewReader(src)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating gzip reader")
+		}
+
+		return gReader, nil
+	case EncodingJSON:
+		return ioutil.NopCloser(src), nil
+	}
+
+	return nil, fmt.Errorf("unsupported manifest encoding '%s'", encoding)
+}
+
+// newEncoder will write the envelope for the encoding to the writer and return a writer that compresses
+// into it. The returned writer must be closed to flush the compressed data.
+func newEncoder(encoding Encoding, dst io.Writer) (io.WriteCloser, error) {
+	if formatByte, ok := formatBytes[encoding]; ok {
+		if _, err := dst.Write(append(append([]byte{}, envelopeMagic...), formatByte)); err != nil {
+			return nil, errors.Wrap(err, "error writing manifest envelope")
+		}
+	}
+
+	switch encoding {
+	case EncodingBrotli, EncodingLegacyBrotli:
+		return brotli.NewWriter(dst), nil
+	case EncodingZstd:
+		zWriter, err := zstd.NewWriter(dst)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating zstd writer")
+		}
+
+		return zWriter, nil
+	case EncodingGzip:
+		return gzip.NewWriter(dst), nil
+	case EncodingJSON:
+		return nopWriteCloser{dst}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported manifest encoding '%s'", encoding)
+}
+
+// nopWriteCloser is a writer with a no-op Close method.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopWriteCloser) Close() error {
+	return nil
+}
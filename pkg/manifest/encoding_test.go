@@ -0,0 +1,84 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-test/deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestEncodings(t *testing.T) {
+	for _, encoding := range Encodings {
+		m := createTestingManifest(t)
+		m.Encoding = encoding
+
+		writer := &bytes.Buffer{}
+		assert.NoError(t, m.WriteManifest(writer), "%s: should have no error writing the manifest", encoding)
+
+		if encoding == EncodingLegacyBrotli {
+			assert.False(t, bytes.HasPrefix(writer.Bytes(), envelopeMagic), "%s: should not have an envelope", encoding)
+		} else {
+			assert.True(t, bytes.HasPrefix(writer.Bytes(), envelopeMagic), "%s: should have an envelope", encoding)
+		}
+
+		readM, err := ReadManifest(bytes.NewReader(writer.Bytes()))
+		assert.NoError(t, err, "%s: reading manifest should have no error", encoding)
+
+		if diff := deep.Equal(m, readM); diff != nil {
+			t.Errorf("%s %s", encoding, diff)
+		}
+	}
+}
+
+func TestReadLegacyManifest(t *testing.T) {
+	m := createTestingManifest(t)
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err, "should have no error marshaling the manifest")
+
+	// Write the manifest the way older versions of conshim did: headerless brotli.
+	writer := &bytes.Buffer{}
+	bWriter := brotli.NewWriter(writer)
+	_, err = bWriter.Write(data)
+	assert.NoError(t, err, "should have no error compressing the manifest")
+	assert.NoError(t, bWriter.Close(), "should have no error closing the brotli writer")
+
+	readM, err := ReadManifest(bytes.NewReader(writer.Bytes()))
+	assert.NoError(t, err, "reading legacy manifest should have no error")
+	assert.Equal(t, EncodingLegacyBrotli, readM.Encoding, "encoding should be detected as legacy brotli")
+
+	m.Encoding = EncodingLegacyBrotli
+	if diff := deep.Equal(m, readM); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestWriteDefaultEncoding(t *testing.T) {
+	m := createTestingManifest(t)
+	m.Encoding = ""
+
+	writer := &bytes.Buffer{}
+	assert.NoError(t, m.WriteManifest(writer), "should have no error writing the manifest")
+	assert.False(t, bytes.HasPrefix(writer.Bytes(), envelopeMagic), "default encoding should not have an envelope, so older clients can read it")
+	assert.Equal(t, DefaultEncoding, CreateManifest(testSourceName).Encoding, "new manifests should use the default encoding")
+}
+
+func TestReadUnknownFormat(t *testing.T) {
+	data := append(append([]byte{}, envelopeMagic...), 0xff)
+	data = append(data, []byte(`{"source":"dummy"}`)...)
+
+	_, err := ReadManifest(bytes.NewReader(data))
+	assert.Error(t, err, "unknown format byte should error")
+}
+
+func TestParseEncoding(t *testing.T) {
+	encoding, err := ParseEncoding("zstd")
+	assert.NoError(t, err, "known encoding should parse")
+	assert.Equal(t, EncodingZstd, encoding, "encodings should match")
+
+	_, err = ParseEncoding("lzma")
+	assert.Error(t, err, "unknown encoding should error")
+}
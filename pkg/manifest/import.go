@@ -0,0 +1,116 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+)
+
+const (
+	// localShimVersion is the version given to shims added directly with conshim shim add.
+	localShimVersion = "NONE"
+
+	// unknownShimVersion is the version reported for shims whose metadata couldn't be parsed.
+	unknownShimVersion = "???"
+)
+
+// SkippedShim is a shim that wasn't imported into the manifest.
+type SkippedShim struct {
+	// Name is the name of the shim.
+	Name string
+
+	// Reason is why the shim was skipped.
+	Reason string
+}
+
+// ImportReport is a summary of shims imported into a manifest.
+type ImportReport struct {
+	// Added are the names of shims that were added to the manifest.
+	Added []string
+
+	// Updated are the names of shims that replaced existing entries in the manifest.
+	Updated []string
+
+	// Skipped are the shims that weren't imported.
+	Skipped []SkippedShim
+}
+
+// Skip will record a shim as skipped.
+func (r *ImportReport) Skip(shimName, reason string) {
+	r.Skipped = append(r.Skipped, SkippedShim{Name: shimName, Reason: reason})
+}
+
+// Changes will return the changelog action for each imported shim, keyed by shim name.
+func (r *ImportReport) Changes() map[string]string {
+	changes := map[string]string{}
+
+	for _, shimName := range r.Added {
+		changes[shimName] = ActionAdd
+	}
+
+	for _, shimName := range r.Updated {
+		changes[shimName] = ActionUpdate
+	}
+
+	return changes
+}
+
+// String will return a string representation of the report.
+func (r *ImportReport) String() string {
+	builder := strings.Builder{}
+
+	for _, shimName := range r.Added {
+		builder.WriteString(fmt.Sprintf("Added shim '%s'.\n", shimName))
+	}
+
+	for _, shimName := range r.Updated {
+		builder.WriteString(fmt.Sprintf("Updated shim '%s'.\n", shimName))
+	}
+
+	for _, skipped := range r.Skipped {
+		builder.WriteString(fmt.Sprintf("Skipped shim '%s': %s\n", skipped.Name, skipped.Reason))
+	}
+
+	return builder.String()
+}
+
+// ImportShim will add the shim to the manifest, recording the outcome in the report. Existing entries are
+// only replaced if overwrite is true. Shims with names that can't be used in the bin directory are skipped.
+func (m *Manifest) ImportShim(shimName string, s shim.Shim, overwrite bool, report *ImportReport) {
+	if err := shim.ValidateName(shimName); err != nil {
+		report.Skip(shimName, err.Error())
+		return
+	}
+
+	if _, ok := m.Shims[shimName]; ok {
+		if !overwrite {
+			report.Skip(shimName, "already exists in the manifest")
+			return
+		}
+
+		m.Shims[shimName] = s
+		report.Updated = append(report.Updated, shimName)
+
+		return
+	}
+
+	m.Shims[shimName] = s
+	report.Added = append(report.Added, shimName)
+}
+
+// FromLocalShim will convert a shim parsed from the local bin directory into a manifest entry. Parameters
+// are detected from the {{parameter}} placeholders left in the command.
+func FromLocalShim(s shim.Shim, description string) shim.Shim {
+	version := s.Version
+	if version == localShimVersion || version == unknownShimVersion {
+		version = ""
+	}
+
+	return shim.Shim{
+		Version:     version,
+		Description: description,
+		Parameters:  shim.DetectParameters(s.Command),
+		Command:     s.Command,
+	}
+}
@@ -0,0 +1,75 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportShim(t *testing.T) {
+	m := createTestingManifest(t)
+	report := &ImportReport{}
+
+	m.ImportShim("new-shim", shim.Shim{Command: "new-command"}, false, report)
+	m.ImportShim("new-shim1", shim.Shim{Command: "replaced-command"}, false, report)
+	m.ImportShim("new-shim2", shim.Shim{Command: "replaced-command"}, true, report)
+	m.ImportShim("../evil", shim.Shim{Command: "evil-command"}, true, report)
+	report.Skip("broken", "unexpected EOF while reading command")
+
+	assert.Equal(t, []string{"new-shim"}, report.Added, "added shims should match")
+	assert.Equal(t, []string{"new-shim2"}, report.Updated, "updated shims should match")
+	assert.Equal(t, []SkippedShim{
+		{Name: "new-shim1", Reason: "already exists in the manifest"},
+		{Name: "../evil", Reason: "shim name '../evil' must not contain path separators"},
+		{Name: "broken", Reason: "unexpected EOF while reading command"},
+	}, report.Skipped, "skipped shims should match")
+	assert.Equal(t, map[string]string{"new-shim": ActionAdd, "new-shim2": ActionUpdate}, report.Changes(), "changes should match")
+
+	assert.NotContains(t, m.Shims, "../evil", "shims with invalid names should not be imported")
+	assert.Equal(t, "my-command1", m.Shims["new-shim1"].Command, "skipped shim should not be replaced")
+	assert.Equal(t, "replaced-command", m.Shims["new-shim2"].Command, "updated shim should be replaced")
+}
+
+func TestFromLocalShim(t *testing.T) {
+	tests := []struct {
+		name        string
+		localShim   shim.Shim
+		description string
+		expected    shim.Shim
+	}{
+		{
+			name: "user shim",
+			localShim: shim.Shim{
+				Name:    "jq",
+				Source:  "user",
+				Version: "NONE",
+				Command: `docker run -v {{dir}}:/work jq "$@"`,
+			},
+			description: "JSON processor",
+			expected: shim.Shim{
+				Description: "JSON processor",
+				Parameters:  []string{"dir"},
+				Command:     `docker run -v {{dir}}:/work jq "$@"`,
+			},
+		},
+		{
+			name: "shim loaded from a registry",
+			localShim: shim.Shim{
+				Name:    "node",
+				Source:  "github.com/some/registry",
+				Version: "20",
+				Command: `docker run node "$@"`,
+			},
+			expected: shim.Shim{
+				Version:    "20",
+				Parameters: []string{},
+				Command:    `docker run node "$@"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, FromLocalShim(test.localShim, test.description), "%s: shims should match", test.name)
+	}
+}
@@ -1,113 +1,376 @@
 package manifest
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 
-	"github.com/andybalholm/brotli"
 	"github.com/meowfaceman/conshim/pkg/shim"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 const (
-	brotliReaderBufferSize = 1024
+	// DefaultMaxManifestSize is the default maximum decompressed size of a manifest.
+	DefaultMaxManifestSize int64 = 64 * 1024 * 1024
+)
+
+var (
+	// ErrManifestTooLarge is returned when a manifest exceeds the maximum decompressed size.
+	ErrManifestTooLarge = errors.New("manifest exceeds the maximum decompressed size")
+
+	// MaxManifestSize is the maximum decompressed size of manifests read by ReadManifest.
+	MaxManifestSize = DefaultMaxManifestSize
 )
 
 // Manifest is a manifest of shims that are housed externally.
 type Manifest struct {
 	// Source is the URL of the registry that this manifest belongs to.
-	Source string `json:"source"`
+	Source string `json:"source" yaml:"source"`
 
 	// Version is the version of the manifest.
-	Version string `json:"version"`
+	Version string `json:"version" yaml:"version"`
 
 	// Shims is a list of shims described by this manifest. The key here is the name of the shim
 	// which corresponds to the executable name for this shim.
-	Shims map[string]shim.Shim `json:"shims"`
+	Shims map[string]shim.Shim `json:"shims" yaml:"shims"`
+
+	// MinConshimVersion is the minimum version of conshim needed to use this manifest.
+	MinConshimVersion string `json:"minConshimVersion,omitempty" yaml:"minConshimVersion,omitempty"`
+
+	// Origin records where a locally cached copy of the manifest was fetched from.
+	Origin *Origin `json:"origin,omitempty" yaml:"origin,omitempty"`
+
+	// Changelog is a record of the changes made to the shims in this manifest, oldest first.
+	Changelog []ChangelogEntry `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+
+	// Encoding is the encoding the manifest was read in and will be written in.
+	Encoding Encoding `json:"-" yaml:"-"`
 }
 
 // CreateManifest will create a new manifest with the given source.
 func CreateManifest(source string) *Manifest {
 	return &Manifest{
-		Source: source,
-		Shims:  map[string]shim.Shim{},
+		Source:   source,
+		Version:  InitialVersion,
+		Shims:    map[string]shim.Shim{},
+		Encoding: DefaultEncoding,
 	}
 }
 
-// ReadManifest will read the manifest from the reader and return it.
+// ReadManifest will read the manifest from the reader and return it. The decompressed manifest may
+// not be larger than MaxManifestSize.
 func ReadManifest(src io.Reader) (*Manifest, error) {
-	bReader := brotli.NewReader(src)
+	return ReadManifestWithLimit(src, MaxManifestSize)
+}
+
+// ReadManifestWithLimit will read the manifest from the reader and return it. The encoding is detected
+// from the manifest's envelope. The manifest is decoded as it is decompressed, and reading will stop
+// with ErrManifestTooLarge once more than maxSize decompressed bytes have been read.
+func ReadManifestWithLimit(src io.Reader, maxSize int64) (*Manifest, error) {
+	encoding, encodedReader, err := detectEncoding(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := newDecoder(encoding, encodedReader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := decoder.Close(); closeErr != nil {
+			zap.S().Errorf("error closing manifest decoder: %v", closeErr)
+		}
+	}()
+
+	bReader := &limitedReader{
+		reader:    decoder,
+		remaining: maxSize,
+	}
+
+	manifest := &Manifest{Encoding: encoding}
+	if err := decodeManifest(newJSONScanner(bReader), manifest); err != nil {
+		if errors.Is(err, ErrManifestTooLarge) {
+			return nil, errors.Wrapf(err, "manifest is larger than %d bytes", maxSize)
+		}
+
+		return nil, errors.Wrap(err, "error decoding manifest")
+	}
+
+	return manifest, nil
+}
+
+// decodeManifest will decode a manifest from the scanner. Shims are decoded one at a time so that the
+// whole manifest never has to be buffered, and the other fields are decoded straight into the manifest.
+// Their raw JSON is only kept in strict mode, to validate it against the schema.
+func decodeManifest(scanner *jsonScanner, m *Manifest) error {
+	var fields map[string]json.RawMessage
+	if Strict {
+		fields = map[string]json.RawMessage{}
+	}
+
+	targets := m.fieldTargets()
+	hasShims := false
+
+	err := scanner.readObject(func(key string) error {
+		if key == "shims" {
+			hasShims = true
+
+			return errors.Wrap(decodeShims(scanner, m), "error decoding shims")
+		}
+
+		return errors.Wrapf(decodeField(scanner, targets[key], key, fields), "error decoding field '%s'", key)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if Strict {
+		return validateManifestFields(fields, hasShims)
+	}
+
+	return nil
+}
+
+// fieldTargets will return where each top-level field of the manifest, other than the shims, is decoded
+// to, keyed by its JSON name.
+func (m *Manifest) fieldTargets() map[string]interface{} {
+	return map[string]interface{}{
+		"source":            &m.Source,
+		"version":           &m.Version,
+		"minConshimVersion": &m.MinConshimVersion,
+		"origin":            &m.Origin,
+		"changelog":         &m.Changelog,
+	}
+}
+
+// decodeField will decode the value of a top-level field into its target. Unknown fields are skipped. If
+// fields isn't nil, the raw JSON of the field is kept in it too.
+func decodeField(scanner *jsonScanner, target interface{}, key string, fields map[string]json.RawMessage) error {
+	raw, err := scanner.readValue()
+
+	if err != nil {
+		return err
+	}
+
+	if fields != nil {
+		fields[key] = append(json.RawMessage{}, raw...)
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	return json.Unmarshal(raw, target)
+}
+
+// validateManifestFields will validate everything other than the shims against the manifest schema. The
+// shims have already been validated one at a time as they were decoded.
+func validateManifestFields(fields map[string]json.RawMessage, hasShims bool) error {
+	if hasShims {
+		fields["shims"] = json.RawMessage("{}")
+	}
+
+	data, err := json.Marshal(fields)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateManifestJSON(data); err != nil {
+		return errors.Wrap(err, "manifest does not match the schema")
+	}
+
+	return nil
+}
+
+// decodeShims will decode the shims object from the scanner into the manifest.
+func decodeShims(scanner *jsonScanner, m *Manifest) error {
+	next, err := scanner.peek()
 
-	data := bytes.Buffer{}
-	for {
-		buf := make([]byte, brotliReaderBufferSize)
-		numRead, err := bReader.Read(buf)
+	if err != nil {
+		return err
+	}
+
+	if next != '{' {
+		raw, err := scanner.readValue()
 
 		if err != nil {
-			// If the error is EOF, break out of the loop gracefully.
-			if err == io.EOF {
-				break
-			}
+			return err
+		}
+
+		if string(raw) == "null" {
+			return nil
+		}
+
+		return fmt.Errorf("expected shims to be an object, got '%s'", raw)
+	}
+
+	if m.Shims == nil {
+		m.Shims = map[string]shim.Shim{}
+	}
+
+	// The shim is decoded into the same variable each time so that it doesn't need a new allocation, since
+	// the map copies it anyway.
+	s := shim.Shim{}
+
+	return scanner.readObject(func(shimName string) error {
+		s = shim.Shim{}
+		if err := decodeShim(scanner, &s); err != nil {
+			return errors.Wrapf(err, "error decoding shim '%s'", shimName)
+		}
+
+		m.Shims[shimName] = s
 
-			return nil, errors.Wrap(err, "error decompressing manifest")
+		return nil
+	})
+}
+
+// decodeShim will decode a single shim from the scanner. Its fields are decoded straight from the stream
+// rather than unmarshaled, which would need the raw shim copied out first. In strict mode the raw shim is
+// needed anyway, to validate it against the shim schema, so it's unmarshaled.
+func decodeShim(scanner *jsonScanner, s *shim.Shim) error {
+	if Strict {
+		raw, err := scanner.readValue()
+
+		if err != nil {
+			return err
 		}
 
-		if numRead == 0 {
-			break
+		if err := ValidateShimJSON(raw); err != nil {
+			return errors.Wrap(err, "shim does not match the schema")
 		}
 
-		data.Write(buf[:numRead])
+		return json.Unmarshal(raw, s)
 	}
 
-	manifest := &Manifest{}
-	if err := json.Unmarshal(data.Bytes(), manifest); err != nil {
-		return nil, errors.Wrap(err, "error unmarshaling decompressed manifest")
+	next, err := scanner.peek()
+
+	if err != nil {
+		return err
 	}
 
-	return manifest, nil
+	if next != '{' {
+		raw, err := scanner.readValue()
+
+		if err != nil || string(raw) == "null" {
+			return err
+		}
+
+		return fmt.Errorf("expected shim to be an object, got '%s'", raw)
+	}
+
+	more, err := scanner.enterObject()
+
+	for more && err == nil {
+		key, keyErr := scanner.readKey()
+
+		if keyErr != nil {
+			return keyErr
+		}
+
+		if err := decodeShimField(scanner, s, key); err != nil {
+			return errors.Wrapf(err, "error decoding field '%s'", key)
+		}
+
+		more, err = scanner.nextMember()
+	}
+
+	return err
 }
 
-// WriteManifest will take the current manifest and write it to the writer.
+// decodeShimField will decode the value of a shim field into the shim. Unknown fields are skipped. This
+// must be kept in step with the JSON names of the shim's fields.
+func decodeShimField(scanner *jsonScanner, s *shim.Shim, key []byte) error {
+	switch string(key) {
+	case "name":
+		return scanner.readString(&s.Name)
+	case "source":
+		return scanner.readString(&s.Source)
+	case "version":
+		return scanner.readString(&s.Version)
+	case "description":
+		return scanner.readString(&s.Description)
+	case "parameters":
+		return scanner.readStrings(&s.Parameters)
+	case "command":
+		return scanner.readString(&s.Command)
+	case "platforms":
+		return scanner.readStrings(&s.Platforms)
+	case "variants":
+		return decodeField(scanner, &s.Variants, "variants", nil)
+	case "tags":
+		return scanner.readStrings(&s.Tags)
+	case "categories":
+		return scanner.readStrings(&s.Categories)
+	case "image":
+		return scanner.readString(&s.Image)
+	case "digest":
+		return scanner.readString(&s.Digest)
+	case "minConshimVersion":
+		return scanner.readString(&s.MinConshimVersion)
+	case "upstream":
+		return decodeField(scanner, &s.Upstream, "upstream", nil)
+	default:
+		_, err := scanner.readValue()
+		return err
+	}
+}
+
+// WriteManifest will take the current manifest and write it to the writer using the manifest's encoding.
 func (m *Manifest) WriteManifest(dst io.Writer) error {
+	encoding := m.Encoding
+	if encoding == "" {
+		encoding = DefaultEncoding
+	}
+
 	data, err := json.Marshal(m)
 
 	if err != nil {
 		return errors.Wrap(err, "error marshaling manifest")
 	}
 
-	bWriter := brotli.NewWriter(dst)
+	encoder, err := newEncoder(encoding, dst)
 
-	defer func() {
-		if closeErr := bWriter.Close(); closeErr != nil {
-			zap.S().Errorf("error closing brotli writer: %v", closeErr)
-		}
-	}()
-	numWritten, err := bWriter.Write(data)
+	if err != nil {
+		return err
+	}
+
+	numWritten, err := encoder.Write(data)
 
 	if err != nil {
-		return errors.Wrap(err, "error compressing marshaled manifest")
+		return errors.Wrapf(err, "error encoding marshaled manifest as %s", encoding)
 	}
 
 	dataLength := len(data)
 	if dataLength != numWritten {
-		return fmt.Errorf("%d bytes written, expected %d during manifest compression", numWritten, dataLength)
+		return fmt.Errorf("%d bytes written, expected %d during manifest encoding", numWritten, dataLength)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return errors.Wrapf(err, "error flushing %s manifest encoder", encoding)
 	}
 
 	return nil
 }
 
 // AddShim will add one or more shims to the manifest. If the shim already exists, this will error.
-func (m *Manifest) AddShim(shimName string, shim shim.Shim) error {
+func (m *Manifest) AddShim(shimName string, s shim.Shim) error {
+	if err := shim.ValidateName(shimName); err != nil {
+		return err
+	}
+
 	if _, ok := m.Shims[shimName]; ok {
 		return fmt.Errorf("shim '%s' already exists in the manifest", shimName)
 	}
 
-	m.Shims[shimName] = shim
+	m.Shims[shimName] = s
 
 	return nil
 }
@@ -154,6 +417,25 @@ func (m *Manifest) SourceHash() string {
 	return SourceHash(m.Source)
 }
 
+// FilterShims will return a copy of the manifest that only contains the shims for which keep returns true.
+func (m *Manifest) FilterShims(keep func(shim.Shim) bool) *Manifest {
+	filtered := &Manifest{
+		Source:            m.Source,
+		Version:           m.Version,
+		Shims:             map[string]shim.Shim{},
+		MinConshimVersion: m.MinConshimVersion,
+	}
+
+	for shimName := range m.Shims {
+		if s, _ := m.GetShim(shimName); keep(s) {
+			filtered.Shims[shimName] = m.Shims[shimName]
+		}
+	}
+
+	return filtered
+}
+
+// ShimsToString will return a string representation of all of the shims in the manifest.
 func (m *Manifest) ShimsToString() string {
 	shims := []shim.Shim{}
 
@@ -171,3 +453,35 @@ func SourceHash(sourceName string) string {
 	hash.Write([]byte(sourceName))
 	return base64.URLEncoding.EncodeToString(hash.Sum(nil))
 }
+
+// limitedReader is a reader that errors with ErrManifestTooLarge once more than the remaining number
+// of bytes have been read. Unlike io.LimitReader, this makes truncation distinguishable from the end
+// of the stream.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+// Read will read from the underlying reader, erroring if the limit is exceeded.
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrManifestTooLarge
+	}
+
+	// Read one byte past the limit so that we can tell if the stream keeps going.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	numRead, err := l.reader.Read(p)
+
+	if int64(numRead) <= l.remaining {
+		l.remaining -= int64(numRead)
+		return numRead, err
+	}
+
+	numRead = int(l.remaining)
+	l.remaining = -1
+
+	return numRead, ErrManifestTooLarge
+}
@@ -2,11 +2,19 @@ package manifest
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/andybalholm/brotli"
+
 	"github.com/go-test/deep"
 	"github.com/hashicorp/go-multierror"
 	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -81,6 +89,19 @@ func TestAddShim(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			name: "add shim outside the bin directory",
+			shimsToAdd: []shimNameAndInfo{
+				{
+					name: "../../.bashrc",
+					shim: shim.Shim{
+						Version: "1234",
+						Command: "my-command",
+					},
+				},
+			},
+			expectedErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -298,6 +319,202 @@ func TestManifestReadAndWrite(t *testing.T) {
 	}
 }
 
+func TestDecodeShim(t *testing.T) {
+	full := shim.Shim{
+		Name:              "jq",
+		Source:            "github.com/org/shims",
+		Version:           "1.6",
+		Description:       "Processes \"JSON\" on the command line.",
+		Parameters:        []string{"tag"},
+		Command:           "docker run --rm -i {{image}} \"$@\"",
+		Platforms:         []string{"linux/amd64", "darwin"},
+		Variants:          map[string]string{"darwin": "docker run --rm -i --platform linux/amd64 {{image}} \"$@\""},
+		Tags:              []string{"json"},
+		Categories:        []string{"data"},
+		Image:             "jq:1.6",
+		Digest:            "sha256:aaaa",
+		MinConshimVersion: "1.2.0",
+		Upstream:          &shim.Upstream{Source: "upstream", Name: "jq", Version: "1", ManifestVersion: "1.0.0", Checksum: "abc"},
+	}
+
+	// Every field is set so that a field added to shims but not to decodeShimField is caught.
+	value := reflect.ValueOf(full)
+	for i := 0; i < value.NumField(); i++ {
+		assert.False(t, value.Field(i).IsZero(), "field %s should be set", value.Type().Field(i).Name)
+	}
+
+	data, err := json.Marshal(full)
+	assert.NoError(t, err, "should be no error marshaling the shim")
+
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr bool
+	}{
+		{name: "every field", input: string(data)},
+		{name: "no fields", input: `{}`},
+		{name: "null", input: `null`},
+		{name: "null fields", input: `{"version": null, "parameters": null, "variants": null, "upstream": null}`},
+		{name: "unknown fields", input: `{"unknown": {"nested": [1, "}"]}, "command": "docker run jq"}`},
+		{name: "wrong type", input: `{"version": 1}`, expectedErr: true},
+		{name: "not an object", input: `[]`, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		expected := shim.Shim{}
+		expectedErr := json.Unmarshal([]byte(test.input), &expected)
+
+		decoded := shim.Shim{}
+		err := decodeShim(newJSONScanner(strings.NewReader(test.input)), &decoded)
+
+		if test.expectedErr {
+			assert.Error(t, expectedErr, "%s: unmarshaling should error", test.name)
+			assert.Error(t, err, "%s: decoding should error", test.name)
+			continue
+		}
+
+		assert.NoError(t, err, "%s: decoding should not error", test.name)
+		assert.Equal(t, expected, decoded, "%s: decoding should match unmarshaling", test.name)
+	}
+}
+
+func TestReadManifestWithLimit(t *testing.T) {
+	m := createTestingManifest(t)
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err, "should have no error marshaling the manifest")
+	size := int64(len(data))
+
+	writer := &bytes.Buffer{}
+	assert.NoError(t, m.WriteManifest(writer), "should have no error writing the manifest")
+
+	tests := []struct {
+		name        string
+		input       []byte
+		maxSize     int64
+		expectedErr error
+	}{
+		{
+			name:    "manifest exactly at the limit",
+			input:   writer.Bytes(),
+			maxSize: size,
+		},
+		{
+			name:        "manifest over the limit",
+			input:       writer.Bytes(),
+			maxSize:     size - 1,
+			expectedErr: ErrManifestTooLarge,
+		},
+		{
+			name:        "not a manifest",
+			input:       []byte("this is not brotli"),
+			maxSize:     size,
+			expectedErr: errors.New("any"),
+		},
+	}
+
+	for _, test := range tests {
+		readM, err := ReadManifestWithLimit(bytes.NewReader(test.input), test.maxSize)
+
+		if test.expectedErr == nil {
+			assert.NoError(t, err, "%s: should have no error", test.name)
+			if diff := deep.Equal(m, readM); diff != nil {
+				t.Errorf("%s %s", test.name, diff)
+			}
+		} else {
+			assert.Error(t, err, "%s: should error", test.name)
+			if test.expectedErr == ErrManifestTooLarge {
+				assert.True(t, errors.Is(err, ErrManifestTooLarge), "%s: should be a too large error", test.name)
+			}
+		}
+	}
+}
+
+func BenchmarkReadManifest(b *testing.B) {
+	for _, numShims := range []int{100, 1000, 5000} {
+		data := createLargeManifest(b, numShims)
+
+		b.Run(fmt.Sprintf("streaming-%d", numShims), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := ReadManifest(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("buffered-%d", numShims), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := readManifestBuffered(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// readManifestBuffered is the previous implementation of ReadManifest, which decompressed the whole
+// manifest with a fresh buffer on each read before unmarshaling. It's kept as a benchmark baseline.
+func readManifestBuffered(src io.Reader) (*Manifest, error) {
+	bReader := brotli.NewReader(src)
+
+	data := bytes.Buffer{}
+	for {
+		buf := make([]byte, 1024)
+		numRead, err := bReader.Read(buf)
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, err
+		}
+
+		if numRead == 0 {
+			break
+		}
+
+		data.Write(buf[:numRead])
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data.Bytes(), manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func createLargeManifest(b *testing.B, numShims int) []byte {
+	m := CreateManifest(testSourceName)
+
+	for i := 0; i < numShims; i++ {
+		shimName := fmt.Sprintf("shim-%d", i)
+		if err := m.AddShim(shimName, shim.Shim{
+			Version:     fmt.Sprintf("%d", i),
+			Description: fmt.Sprintf("A description of shim %d that is long enough to be realistic.", i),
+			Command:     fmt.Sprintf("docker run --rm -it -v \"$(pwd):/work\" registry.example.com/tools/%s:latest \"$@\"", shimName),
+			Parameters:  []string{"param1", "param2"},
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// The buffered baseline only reads raw brotli, so the manifest is written without an envelope.
+	m.Encoding = EncodingLegacyBrotli
+
+	writer := &bytes.Buffer{}
+	if err := m.WriteManifest(writer); err != nil {
+		b.Fatal(err)
+	}
+
+	return writer.Bytes()
+}
+
 func createTestingManifest(t *testing.T) *Manifest {
 	shimsToAdd := []shimNameAndInfo{
 		{
@@ -334,3 +551,20 @@ func createTestingManifest(t *testing.T) *Manifest {
 
 	return m
 }
+
+func TestFilterShims(t *testing.T) {
+	m := createTestingManifest(t)
+	assert.NoError(t, m.AddShim("amd64-only", shim.Shim{
+		Version:   "1",
+		Command:   "amd64-command",
+		Platforms: []string{"linux/amd64"},
+	}), "should not error")
+
+	filtered := m.FilterShims(func(s shim.Shim) bool {
+		return s.IsCompatible("linux", "arm64")
+	})
+
+	assert.Len(t, filtered.Shims, 3, "incompatible shim should be filtered out")
+	assert.NotContains(t, filtered.Shims, "amd64-only", "incompatible shim should be filtered out")
+	assert.Len(t, m.Shims, 4, "original manifest should be unchanged")
+}
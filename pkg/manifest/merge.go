@@ -0,0 +1,238 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/meowfaceman/conshim/pkg/semver"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict is a change to the manifest that couldn't be merged automatically.
+type Conflict struct {
+	// Shim is the name of the conflicting shim, or empty if the conflict is in the manifest itself.
+	Shim string
+
+	// Reason describes the conflicting changes.
+	Reason string
+}
+
+// String will return a string representation of the conflict.
+func (c Conflict) String() string {
+	if c.Shim == "" {
+		return c.Reason
+	}
+
+	return fmt.Sprintf("shim '%s': %s", c.Shim, c.Reason)
+}
+
+// ToYAML will render the manifest as YAML with its shims sorted by name, such that the same manifest
+// always renders the same way regardless of its encoding. This makes manifests diffable.
+func (m *Manifest) ToYAML() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(m); err != nil {
+		return nil, errors.Wrap(err, "error rendering manifest as YAML")
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "error rendering manifest as YAML")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Merge will do a three-way merge of two manifests that were both changed from a common base. Shims
+// changed on only one side take that side's change, and shims changed identically on both sides are
+// merged cleanly. Shims changed differently on both sides are conflicts and keep our side. The merged
+// manifest takes a version past both sides' versions, the higher of the two minimum conshim versions, the
+// changelog entries added on either side, and our encoding.
+func Merge(base, ours, theirs *Manifest) (*Manifest, []Conflict) {
+	conflicts := []Conflict{}
+
+	merged := &Manifest{
+		Source:            ours.Source,
+		Version:           mergeManifestVersions(base.Version, ours.Version, theirs.Version),
+		Shims:             map[string]shim.Shim{},
+		MinConshimVersion: mergeVersions(ours.MinConshimVersion, theirs.MinConshimVersion),
+		Encoding:          ours.Encoding,
+	}
+
+	if ours.Source != theirs.Source && theirs.Source != base.Source {
+		if ours.Source == base.Source {
+			merged.Source = theirs.Source
+		} else {
+			conflicts = append(conflicts, Conflict{
+				Reason: fmt.Sprintf("source changed to '%s' and '%s'", ours.Source, theirs.Source),
+			})
+		}
+	}
+
+	for _, shimName := range shimNames(base, ours, theirs) {
+		baseShim, inBase := base.Shims[shimName]
+		ourShim, inOurs := ours.Shims[shimName]
+		theirShim, inTheirs := theirs.Shims[shimName]
+
+		switch {
+		case sameShim(ourShim, inOurs, theirShim, inTheirs), sameShim(baseShim, inBase, theirShim, inTheirs):
+			if inOurs {
+				merged.Shims[shimName] = ourShim
+			}
+		case sameShim(baseShim, inBase, ourShim, inOurs):
+			if inTheirs {
+				merged.Shims[shimName] = theirShim
+			}
+		default:
+			conflicts = append(conflicts, Conflict{Shim: shimName, Reason: conflictReason(inBase, inOurs, inTheirs)})
+
+			if inOurs {
+				merged.Shims[shimName] = ourShim
+			}
+		}
+	}
+
+	merged.Changelog = mergeChangelogs(base.Changelog, ours.Changelog, theirs.Changelog)
+
+	return merged, conflicts
+}
+
+// shimNames will return the sorted names of the shims in any of the manifests.
+func shimNames(manifests ...*Manifest) []string {
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, m := range manifests {
+		for shimName := range m.Shims {
+			if !seen[shimName] {
+				seen[shimName] = true
+				names = append(names, shimName)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// sameShim will return true if both sides have the same shim, or both sides don't have it.
+func sameShim(a shim.Shim, inA bool, b shim.Shim, inB bool) bool {
+	if inA != inB {
+		return false
+	}
+
+	return !inA || reflect.DeepEqual(normalizeShim(a), normalizeShim(b))
+}
+
+// normalizeShim will clear the differences between shims that don't matter once they're written, such as
+// nil and empty lists.
+func normalizeShim(s shim.Shim) shim.Shim {
+	s.Name = ""
+	s.Source = ""
+
+	for _, list := range []*[]string{&s.Parameters, &s.Platforms, &s.Tags, &s.Categories} {
+		if len(*list) == 0 {
+			*list = nil
+		}
+	}
+
+	if len(s.Variants) == 0 {
+		s.Variants = nil
+	}
+
+	return s
+}
+
+// conflictReason will describe a conflict given which sides have the shim.
+func conflictReason(inBase, inOurs, inTheirs bool) string {
+	switch {
+	case !inBase:
+		return "added on both sides with different contents"
+	case !inOurs:
+		return "removed on our side but changed on their side"
+	case !inTheirs:
+		return "changed on our side but removed on their side"
+	default:
+		return "changed differently on both sides"
+	}
+}
+
+// mergeManifestVersions will return the higher of the two manifest versions. If both sides changed the
+// version to different versions, the merge holds changes neither version was released with, so the higher
+// version is bumped once more. Both sides changing it to the same version are taken to be the same release.
+func mergeManifestVersions(base, ours, theirs string) string {
+	merged := mergeVersions(ours, theirs)
+
+	if ours == base || theirs == base || ours == theirs {
+		return merged
+	}
+
+	version, err := semver.Parse(merged)
+
+	if err != nil {
+		return merged
+	}
+
+	bumped, err := version.Bump(semver.BumpPatch)
+
+	if err != nil {
+		return merged
+	}
+
+	return bumped.String()
+}
+
+// mergeVersions will return the higher of the two versions. Unparseable versions lose to parseable ones.
+func mergeVersions(ours, theirs string) string {
+	ourVersion, ourErr := semver.Parse(ours)
+	theirVersion, theirErr := semver.Parse(theirs)
+
+	if ourErr != nil && theirErr == nil {
+		return theirs
+	}
+
+	if ourErr == nil && theirErr == nil && ourVersion.LessThan(theirVersion) {
+		return theirs
+	}
+
+	return ours
+}
+
+// mergeChangelogs will return the base changelog followed by the entries added on either side, in order
+// of their timestamps. Entries added identically on both sides are only kept once.
+func mergeChangelogs(base, ours, theirs []ChangelogEntry) []ChangelogEntry {
+	merged := append([]ChangelogEntry{}, base...)
+
+	seen := map[string]bool{}
+	for _, entry := range base {
+		seen[changelogKey(entry)] = true
+	}
+
+	added := []ChangelogEntry{}
+	for _, entries := range [][]ChangelogEntry{ours, theirs} {
+		for _, entry := range entries {
+			if key := changelogKey(entry); !seen[key] {
+				seen[key] = true
+				added = append(added, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Timestamp.Before(added[j].Timestamp)
+	})
+
+	return append(merged, added...)
+}
+
+// changelogKey will return a key that identifies the changelog entry.
+func changelogKey(entry ChangelogEntry) string {
+	return entry.String()
+}
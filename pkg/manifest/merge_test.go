@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	jq := shim.Shim{Version: "1", Command: "docker run jq"}
+	jq2 := shim.Shim{Version: "2", Command: "docker run jq:2"}
+	jq3 := shim.Shim{Version: "3", Command: "docker run jq:3"}
+	yq := shim.Shim{Version: "1", Command: "docker run yq"}
+
+	tests := []struct {
+		name              string
+		base              map[string]shim.Shim
+		ours              map[string]shim.Shim
+		theirs            map[string]shim.Shim
+		expected          map[string]shim.Shim
+		expectedConflicts []Conflict
+	}{
+		{
+			name:     "disjoint additions",
+			base:     map[string]shim.Shim{},
+			ours:     map[string]shim.Shim{"jq": jq},
+			theirs:   map[string]shim.Shim{"yq": yq},
+			expected: map[string]shim.Shim{"jq": jq, "yq": yq},
+		},
+		{
+			name:     "change on their side",
+			base:     map[string]shim.Shim{"jq": jq, "yq": yq},
+			ours:     map[string]shim.Shim{"jq": jq},
+			theirs:   map[string]shim.Shim{"jq": jq2, "yq": yq},
+			expected: map[string]shim.Shim{"jq": jq2},
+		},
+		{
+			name:     "identical changes on both sides",
+			base:     map[string]shim.Shim{"jq": jq},
+			ours:     map[string]shim.Shim{"jq": jq2},
+			theirs:   map[string]shim.Shim{"jq": {Version: "2", Command: "docker run jq:2", Tags: []string{}}},
+			expected: map[string]shim.Shim{"jq": jq2},
+		},
+		{
+			name:              "different changes on both sides",
+			base:              map[string]shim.Shim{"jq": jq},
+			ours:              map[string]shim.Shim{"jq": jq2},
+			theirs:            map[string]shim.Shim{"jq": jq3},
+			expected:          map[string]shim.Shim{"jq": jq2},
+			expectedConflicts: []Conflict{{Shim: "jq", Reason: "changed differently on both sides"}},
+		},
+		{
+			name:              "removed on one side and changed on the other",
+			base:              map[string]shim.Shim{"jq": jq},
+			ours:              map[string]shim.Shim{},
+			theirs:            map[string]shim.Shim{"jq": jq2},
+			expected:          map[string]shim.Shim{},
+			expectedConflicts: []Conflict{{Shim: "jq", Reason: "removed on our side but changed on their side"}},
+		},
+		{
+			name:              "added on both sides",
+			base:              map[string]shim.Shim{},
+			ours:              map[string]shim.Shim{"jq": jq},
+			theirs:            map[string]shim.Shim{"jq": jq2},
+			expected:          map[string]shim.Shim{"jq": jq},
+			expectedConflicts: []Conflict{{Shim: "jq", Reason: "added on both sides with different contents"}},
+		},
+	}
+
+	for _, test := range tests {
+		base := &Manifest{Source: testSourceName, Version: "1.0.0", Shims: test.base}
+		ours := &Manifest{Source: testSourceName, Version: "1.0.1", Shims: test.ours}
+		theirs := &Manifest{Source: testSourceName, Version: "1.1.0", Shims: test.theirs}
+
+		merged, conflicts := Merge(base, ours, theirs)
+
+		expectedConflicts := test.expectedConflicts
+		if expectedConflicts == nil {
+			expectedConflicts = []Conflict{}
+		}
+
+		assert.Equal(t, test.expected, merged.Shims, "%s: shims should match", test.name)
+		assert.Equal(t, expectedConflicts, conflicts, "%s: conflicts should match", test.name)
+		assert.Equal(t, "1.1.1", merged.Version, "%s: the version should be bumped past both sides", test.name)
+	}
+}
+
+func TestMergeManifestVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		ours     string
+		theirs   string
+		expected string
+	}{
+		{name: "unchanged", base: "1.0.0", ours: "1.0.0", theirs: "1.0.0", expected: "1.0.0"},
+		{name: "changed on our side", base: "1.0.0", ours: "1.0.1", theirs: "1.0.0", expected: "1.0.1"},
+		{name: "changed on their side", base: "1.0.0", ours: "1.0.0", theirs: "1.1.0", expected: "1.1.0"},
+		{name: "changed on both sides", base: "1.0.0", ours: "1.0.1", theirs: "1.1.0", expected: "1.1.1"},
+		{name: "changed to the same version", base: "1.0.0", ours: "1.0.1", theirs: "1.0.1", expected: "1.0.1"},
+		{name: "added on both sides", base: "", ours: "1.0.0", theirs: "1.0.0", expected: "1.0.0"},
+		{name: "added with different versions", base: "", ours: "1.0.0", theirs: "1.1.0", expected: "1.1.1"},
+		{name: "unparseable on one side", base: "1.0.0", ours: "bogus", theirs: "1.0.1", expected: "1.0.2"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, mergeManifestVersions(test.base, test.ours, test.theirs), "%s: versions should match", test.name)
+	}
+}
+
+func TestMergeChangelogs(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(minutes int, shimName string) ChangelogEntry {
+		return ChangelogEntry{Version: "1.0.0", Shim: shimName, Action: ActionAdd, Timestamp: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	base := []ChangelogEntry{entry(0, "base")}
+	ours := []ChangelogEntry{entry(0, "base"), entry(2, "ours"), entry(3, "both")}
+	theirs := []ChangelogEntry{entry(0, "base"), entry(1, "theirs"), entry(3, "both")}
+
+	merged := mergeChangelogs(base, ours, theirs)
+
+	assert.Equal(t, []ChangelogEntry{entry(0, "base"), entry(1, "theirs"), entry(2, "ours"), entry(3, "both")}, merged, "changelogs should be merged in order")
+}
+
+func TestMergeSourceConflict(t *testing.T) {
+	base := &Manifest{Source: "base", Shims: map[string]shim.Shim{}}
+	ours := &Manifest{Source: "ours", Shims: map[string]shim.Shim{}}
+	theirs := &Manifest{Source: "theirs", Shims: map[string]shim.Shim{}}
+
+	_, conflicts := Merge(base, ours, theirs)
+	assert.Len(t, conflicts, 1, "changing the source on both sides should conflict")
+
+	merged, conflicts := Merge(base, base, theirs)
+	assert.Empty(t, conflicts, "changing the source on one side should not conflict")
+	assert.Equal(t, "theirs", merged.Source, "the changed source should be kept")
+}
+
+func TestToYAML(t *testing.T) {
+	m := CreateManifest(testSourceName)
+	m.Shims["yq"] = shim.Shim{Command: "docker run yq"}
+	m.Shims["jq"] = shim.Shim{Command: "docker run jq", Tags: []string{"json"}}
+
+	first, err := m.ToYAML()
+	assert.NoError(t, err, "should be no error rendering YAML")
+
+	second, err := m.ToYAML()
+	assert.NoError(t, err, "should be no error rendering YAML")
+
+	rendered := string(first)
+	assert.Equal(t, rendered, string(second), "rendering should be deterministic")
+	assert.Less(t, strings.Index(rendered, "jq:"), strings.Index(rendered, "yq:"), "shims should be sorted")
+	assert.Contains(t, rendered, "tags:\n      - json\n", "lists should be rendered")
+	assert.NotContains(t, rendered, "encoding", "the encoding should not be rendered")
+}
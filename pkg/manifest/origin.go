@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Origin records where a locally cached copy of a registry manifest was fetched from. It's only set on
+// cached copies, never on the manifests published by registries.
+type Origin struct {
+	// URL is the URL of the registry.
+	URL string `json:"url" yaml:"url"`
+
+	// Ref is the branch, tag or commit the registry is pinned to, or empty for the default branch.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+
+	// Path is the path of the manifest within the registry, or empty for the manifest at the root.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Commit is the commit the manifest was read from, for registries hosted in git.
+	Commit string `json:"commit,omitempty" yaml:"commit,omitempty"`
+
+	// FetchedAt is when the manifest was fetched.
+	FetchedAt time.Time `json:"fetchedAt" yaml:"fetchedAt"`
+
+	// Auth is how the registry authenticates, or nil for the defaults.
+	Auth *Auth `json:"auth,omitempty" yaml:"auth,omitempty"`
+}
+
+// Auth is how a private registry authenticates. It only names where credentials come from, such as key
+// files and environment variables, and never holds the secrets themselves.
+type Auth struct {
+	// SSHKeyFile is the private key used for SSH registries. If it's empty, the SSH agent is used.
+	SSHKeyFile string `json:"sshKeyFile,omitempty" yaml:"sshKeyFile,omitempty"`
+
+	// SSHKeyPassphraseEnv is the environment variable holding the passphrase of the SSH key, if it has one.
+	SSHKeyPassphraseEnv string `json:"sshKeyPassphraseEnv,omitempty" yaml:"sshKeyPassphraseEnv,omitempty"`
+
+	// KnownHostsFile is the known_hosts file host keys are checked against. If it's empty, the user's and
+	// the system's known_hosts files are used.
+	KnownHostsFile string `json:"knownHostsFile,omitempty" yaml:"knownHostsFile,omitempty"`
+
+	// Username is the username for HTTP(S) registries. If it's empty, the username from the URL is used.
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+
+	// TokenEnv is the environment variable holding the password or token for HTTP(S) registries.
+	TokenEnv string `json:"tokenEnv,omitempty" yaml:"tokenEnv,omitempty"`
+
+	// CredentialHelper asks git's credential helpers for the credentials of HTTP(S) registries.
+	CredentialHelper bool `json:"credentialHelper,omitempty" yaml:"credentialHelper,omitempty"`
+}
+
+// IsZero will return true if the auth only uses the defaults.
+func (a Auth) IsZero() bool {
+	return a == Auth{}
+}
+
+// String will return a string representation of the auth.
+func (a Auth) String() string {
+	methods := []string{}
+
+	if a.SSHKeyFile != "" {
+		methods = append(methods, fmt.Sprintf("SSH key %s", a.SSHKeyFile))
+	}
+
+	if a.KnownHostsFile != "" {
+		methods = append(methods, fmt.Sprintf("known hosts %s", a.KnownHostsFile))
+	}
+
+	if a.TokenEnv != "" {
+		methods = append(methods, fmt.Sprintf("token from $%s", a.TokenEnv))
+	}
+
+	if a.CredentialHelper {
+		methods = append(methods, "git credential helper")
+	}
+
+	if a.Username != "" {
+		methods = append(methods, fmt.Sprintf("username %s", a.Username))
+	}
+
+	if len(methods) == 0 {
+		return "default"
+	}
+
+	return strings.Join(methods, ", ")
+}
+
+// String will return a string representation of the origin.
+func (o *Origin) String() string {
+	builder := strings.Builder{}
+
+	builder.WriteString(fmt.Sprintf("       URL: %s\n", o.URL))
+
+	if o.Path != "" {
+		builder.WriteString(fmt.Sprintf("      Path: %s\n", o.Path))
+	}
+
+	// Only git registries have refs and commits.
+	if o.Commit != "" {
+		ref := o.Ref
+		if ref == "" {
+			ref = "(default branch)"
+		}
+
+		builder.WriteString(fmt.Sprintf("       Ref: %s\n", ref))
+		builder.WriteString(fmt.Sprintf("    Commit: %s\n", o.Commit))
+	}
+
+	if o.Auth != nil {
+		builder.WriteString(fmt.Sprintf("      Auth: %s\n", o.Auth))
+	}
+
+	builder.WriteString(fmt.Sprintf("Fetched at: %s\n", o.FetchedAt.Format(time.RFC3339)))
+
+	return builder.String()
+}
@@ -0,0 +1,182 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// PatchType is the kind of patch document applied to a manifest.
+type PatchType string
+
+const (
+	// PatchTypeJSONPatch is an RFC 6902 JSON Patch, which is a list of operations.
+	PatchTypeJSONPatch PatchType = "json-patch"
+
+	// PatchTypeMergePatch is an RFC 7386 JSON merge patch, which is a partial document to merge.
+	PatchTypeMergePatch PatchType = "merge-patch"
+)
+
+// ParsePatchType will parse the name of a patch type.
+func ParsePatchType(name string) (PatchType, error) {
+	switch PatchType(name) {
+	case PatchTypeJSONPatch, PatchTypeMergePatch:
+		return PatchType(name), nil
+	default:
+		return "", fmt.Errorf("unknown patch type '%s', expected one of %s, %s", name, PatchTypeJSONPatch, PatchTypeMergePatch)
+	}
+}
+
+// DetectPatchType will detect the type of a patch document. JSON Patches are lists and merge patches are
+// objects.
+func DetectPatchType(patch []byte) (PatchType, error) {
+	trimmed := bytes.TrimSpace(patch)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return PatchTypeJSONPatch, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return PatchTypeMergePatch, nil
+	default:
+		return "", errors.New("patch must be a JSON list of operations or a JSON object to merge")
+	}
+}
+
+// Patch will apply the patch document to the manifest as it's serialized, and return the patched manifest.
+// The original manifest is left untouched. The patched manifest must match the manifest schema and the
+// shims changed by the patch must be valid. Shims the patch didn't touch are neither checked against the
+// schema nor validated, so that patches aren't blocked by unrelated shims.
+func (m *Manifest) Patch(patchType PatchType, patch []byte) (*Manifest, error) {
+	original, err := json.Marshal(m)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling manifest")
+	}
+
+	var patched []byte
+	switch patchType {
+	case PatchTypeJSONPatch:
+		operations, decodeErr := jsonpatch.DecodePatch(patch)
+
+		if decodeErr != nil {
+			return nil, errors.Wrap(decodeErr, "error decoding JSON patch")
+		}
+
+		patched, err = operations.Apply(original)
+	case PatchTypeMergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	default:
+		return nil, fmt.Errorf("unknown patch type '%s'", patchType)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error applying %s", patchType)
+	}
+
+	touched, err := withoutUntouchedShims(original, patched)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding patched manifest")
+	}
+
+	if err := ValidateManifestJSON(touched); err != nil {
+		return nil, errors.Wrap(err, "patched manifest does not match the schema")
+	}
+
+	result := &Manifest{Encoding: m.Encoding}
+	if err := json.Unmarshal(patched, result); err != nil {
+		return nil, errors.Wrap(err, "error decoding patched manifest")
+	}
+
+	if result.Shims == nil {
+		return nil, errors.New("patched manifest has no shims")
+	}
+
+	invalid := &multierror.Error{}
+	changes := Diff(m, result)
+	for _, shimName := range sortedChanges(changes) {
+		if changes[shimName] == ActionRemove {
+			continue
+		}
+
+		shimErrs := &multierror.Error{}
+		if err := result.Shims[shimName].Validate(); errors.As(err, &shimErrs) {
+			for _, shimErr := range shimErrs.Errors {
+				invalid = multierror.Append(invalid, fmt.Errorf("shim '%s': %v", shimName, shimErr))
+			}
+		}
+	}
+
+	if err := invalid.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// withoutUntouchedShims will return the patched manifest document with only the shims whose JSON differs
+// from the original document. Shims are compared as decoded JSON, since patching can reorder their fields,
+// and fields the shim type doesn't know about count as changes.
+func withoutUntouchedShims(original, patched []byte) ([]byte, error) {
+	var originalDocument struct {
+		Shims map[string]interface{} `json:"shims"`
+	}
+	if err := json.Unmarshal(original, &originalDocument); err != nil {
+		return nil, err
+	}
+
+	document := map[string]json.RawMessage{}
+	if err := json.Unmarshal(patched, &document); err != nil {
+		return nil, err
+	}
+
+	patchedShims := map[string]json.RawMessage{}
+	if err := json.Unmarshal(document["shims"], &patchedShims); err != nil {
+		return nil, err
+	}
+
+	for shimName, data := range patchedShims {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+
+		if originalShim, ok := originalDocument.Shims[shimName]; ok && reflect.DeepEqual(originalShim, value) {
+			delete(patchedShims, shimName)
+		}
+	}
+
+	// Marshaling maps of raw messages that were just unmarshaled can't fail.
+	document["shims"], _ = json.Marshal(patchedShims)
+
+	return json.Marshal(document)
+}
+
+// Diff will return the changelog action for each shim that differs between the two manifests, keyed by
+// shim name.
+func Diff(before, after *Manifest) map[string]string {
+	changes := map[string]string{}
+
+	for _, shimName := range shimNames(before, after) {
+		beforeShim, inBefore := before.Shims[shimName]
+		afterShim, inAfter := after.Shims[shimName]
+
+		switch {
+		case sameShim(beforeShim, inBefore, afterShim, inAfter):
+			continue
+		case !inBefore:
+			changes[shimName] = ActionAdd
+		case !inAfter:
+			changes[shimName] = ActionRemove
+		default:
+			changes[shimName] = ActionUpdate
+		}
+	}
+
+	return changes
+}
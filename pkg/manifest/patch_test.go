@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		patchType       PatchType
+		patch           string
+		expected        map[string]shim.Shim
+		expectedChanges map[string]string
+		expectedErr     bool
+	}{
+		{
+			name:      "merge patch keeps unspecified fields",
+			patchType: PatchTypeMergePatch,
+			patch:     `{"shims": {"jq": {"image": "jq:1.7"}}}`,
+			expected: map[string]shim.Shim{
+				"jq": {Version: "1", Description: "json", Command: "docker run {{image}}", Image: "jq:1.7"},
+				"yq": {Version: "1", Command: "docker run yq"},
+			},
+			expectedChanges: map[string]string{"jq": ActionUpdate},
+		},
+		{
+			name:      "merge patch removes shims",
+			patchType: PatchTypeMergePatch,
+			patch:     `{"shims": {"yq": null}}`,
+			expected: map[string]shim.Shim{
+				"jq": {Version: "1", Description: "json", Command: "docker run {{image}}", Image: "jq:1.6"},
+			},
+			expectedChanges: map[string]string{"yq": ActionRemove},
+		},
+		{
+			name:      "json patch",
+			patchType: PatchTypeJSONPatch,
+			patch: `[
+				{"op": "test", "path": "/shims/jq/image", "value": "jq:1.6"},
+				{"op": "replace", "path": "/shims/jq/image", "value": "jq:1.7"},
+				{"op": "add", "path": "/shims/gojq", "value": {"version": "1", "parameters": [], "command": "docker run gojq"}}
+			]`,
+			expected: map[string]shim.Shim{
+				"gojq": {Version: "1", Parameters: []string{}, Command: "docker run gojq"},
+				"jq":   {Version: "1", Description: "json", Command: "docker run {{image}}", Image: "jq:1.7"},
+				"yq":   {Version: "1", Command: "docker run yq"},
+			},
+			expectedChanges: map[string]string{"gojq": ActionAdd, "jq": ActionUpdate},
+		},
+		{
+			name:        "failed json patch test",
+			patchType:   PatchTypeJSONPatch,
+			patch:       `[{"op": "test", "path": "/shims/jq/image", "value": "jq:1.5"}]`,
+			expectedErr: true,
+		},
+		{
+			name:        "patch result does not match the schema",
+			patchType:   PatchTypeMergePatch,
+			patch:       `{"shims": {"jq": {"comand": "typo"}}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "patch result has an invalid shim",
+			patchType:   PatchTypeMergePatch,
+			patch:       `{"shims": {"jq": {"image": null}}}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		m := CreateManifest(testSourceName)
+		m.Shims["jq"] = shim.Shim{Version: "1", Description: "json", Command: "docker run {{image}}", Image: "jq:1.6"}
+		m.Shims["yq"] = shim.Shim{Version: "1", Command: "docker run yq"}
+
+		patched, err := m.Patch(test.patchType, []byte(test.patch))
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal, got %v", test.name, err)
+		assert.Equal(t, "jq:1.6", m.Shims["jq"].Image, "%s: the original manifest should not be changed", test.name)
+
+		if !test.expectedErr {
+			assert.Equal(t, test.expected, patched.Shims, "%s: shims should match", test.name)
+			assert.Equal(t, test.expectedChanges, Diff(m, patched), "%s: changes should match", test.name)
+			assert.Equal(t, m.Encoding, patched.Encoding, "%s: the encoding should be kept", test.name)
+		}
+	}
+
+	m := CreateManifest(testSourceName)
+	m.Shims["jq"] = shim.Shim{Version: "1", Command: "docker run {{image}}", Image: "jq:1.6"}
+	m.Shims["legacy"] = shim.Shim{Version: "1", Command: "docker run legacy", Digest: "not-a-digest"}
+
+	_, err := m.Patch(PatchTypeMergePatch, []byte(`{"shims": {"jq": {"image": "jq:1.7"}}}`))
+	assert.NoError(t, err, "untouched shims that don't match the schema should not block the patch")
+
+	_, err = m.Patch(PatchTypeMergePatch, []byte(`{"shims": {"legacy": {"version": "2"}}}`))
+	assert.Error(t, err, "touched shims that don't match the schema should block the patch")
+}
+
+func TestDetectPatchType(t *testing.T) {
+	tests := []struct {
+		patch       string
+		expected    PatchType
+		expectedErr bool
+	}{
+		{patch: ` [{"op": "remove", "path": "/shims/jq"}]`, expected: PatchTypeJSONPatch},
+		{patch: "\n{\"shims\": {}}", expected: PatchTypeMergePatch},
+		{patch: `"string"`, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		patchType, err := DetectPatchType([]byte(test.patch))
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.patch)
+		assert.Equal(t, test.expected, patchType, "%s: patch types should match", test.patch)
+	}
+}
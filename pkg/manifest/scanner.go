@@ -0,0 +1,487 @@
+package manifest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonScanner splits a stream of JSON into object keys and raw values, so that a large object can be
+// decoded one member at a time. Strings can be decoded directly, and other values aren't checked beyond
+// finding where they end, which is left to unmarshaling them.
+type jsonScanner struct {
+	reader *bufio.Reader
+
+	// value is the buffer the last raw value was read into. It's reused for every value.
+	value []byte
+
+	// unescaped is the buffer strings with escapes are unescaped into. It's reused for every string.
+	unescaped []byte
+
+	// strings is the buffer arrays of strings are collected in before they're copied into a slice of the
+	// right size. It's reused for every array.
+	strings []string
+}
+
+// newJSONScanner will create a scanner reading JSON from the reader.
+func newJSONScanner(src io.Reader) *jsonScanner {
+	return &jsonScanner{reader: bufio.NewReader(src)}
+}
+
+// readObject will read an object, calling member with the key of each of its members. The member function
+// must consume the member's value, with readValue or readObject.
+func (s *jsonScanner) readObject(member func(key string) error) error {
+	more, err := s.enterObject()
+
+	for more && err == nil {
+		key, keyErr := s.readKey()
+
+		if keyErr != nil {
+			return keyErr
+		}
+
+		if err := member(string(key)); err != nil {
+			return err
+		}
+
+		more, err = s.nextMember()
+	}
+
+	return err
+}
+
+// enterObject will read the opening brace of an object, returning whether the object has any members.
+func (s *jsonScanner) enterObject() (bool, error) {
+	if err := s.expect('{'); err != nil {
+		return false, err
+	}
+
+	next, err := s.peek()
+
+	if err != nil {
+		return false, err
+	}
+
+	if next == '}' {
+		return false, s.expect('}')
+	}
+
+	return true, nil
+}
+
+// nextMember will read the delimiter after the value of an object member, returning whether another member
+// follows it.
+func (s *jsonScanner) nextMember() (bool, error) {
+	next, err := s.next()
+
+	if err != nil {
+		return false, err
+	}
+
+	switch next {
+	case ',':
+		return true, nil
+	case '}':
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected ',' or '}' after object member, got '%c'", next)
+	}
+}
+
+// readKey will read the key of an object member and the colon following it. The returned bytes are only
+// valid until the next value is read.
+func (s *jsonScanner) readKey() ([]byte, error) {
+	raw, err := s.readValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 || raw[0] != '"' {
+		return nil, fmt.Errorf("expected an object key, got '%s'", raw)
+	}
+
+	key, err := s.unquote(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key, s.expect(':')
+}
+
+// readString will read a string value into the target. A null leaves the target unchanged, like
+// unmarshaling does.
+func (s *jsonScanner) readString(target *string) error {
+	raw, err := s.readValue()
+
+	if err != nil {
+		return err
+	}
+
+	if string(raw) == "null" {
+		return nil
+	}
+
+	if raw[0] != '"' {
+		return fmt.Errorf("expected a string, got '%s'", raw)
+	}
+
+	value, err := s.unquote(raw)
+
+	if err != nil {
+		return err
+	}
+
+	*target = string(value)
+
+	return nil
+}
+
+// readStrings will read an array of strings into the target. A null clears the target, like unmarshaling
+// does.
+func (s *jsonScanner) readStrings(target *[]string) error {
+	next, err := s.peek()
+
+	if err != nil {
+		return err
+	}
+
+	if next != '[' {
+		raw, err := s.readValue()
+
+		if err != nil {
+			return err
+		}
+
+		if string(raw) != "null" {
+			return fmt.Errorf("expected an array of strings, got '%s'", raw)
+		}
+
+		*target = nil
+
+		return nil
+	}
+
+	if err := s.expect('['); err != nil {
+		return err
+	}
+
+	if next, err = s.peek(); err != nil || next == ']' {
+		*target = []string{}
+		return s.expect(']')
+	}
+
+	values := s.strings[:0]
+
+	for {
+		value := ""
+		if err := s.readString(&value); err != nil {
+			return err
+		}
+
+		values = append(values, value)
+		s.strings = values
+
+		next, err := s.next()
+
+		if err != nil {
+			return err
+		}
+
+		switch next {
+		case ',':
+			continue
+		case ']':
+			*target = append(make([]string, 0, len(values)), values...)
+			return nil
+		default:
+			return fmt.Errorf("expected ',' or ']' after array element, got '%c'", next)
+		}
+	}
+}
+
+// unquote will return the contents of a raw JSON string. Strings of plain ASCII without escapes, which most
+// are, are sliced out of the raw JSON directly. The returned bytes are only valid until the next value is
+// read.
+func (s *jsonScanner) unquote(raw []byte) ([]byte, error) {
+	contents := raw[1 : len(raw)-1]
+
+	for _, c := range contents {
+		if c == '\\' || c < ' ' || c >= utf8.RuneSelf {
+			return s.unescape(contents)
+		}
+	}
+
+	return contents, nil
+}
+
+// unescape will unescape the contents of a JSON string into the unescaped buffer. Invalid UTF-8 is
+// replaced with the replacement character, like unmarshaling does.
+func (s *jsonScanner) unescape(contents []byte) ([]byte, error) {
+	unescaped := s.unescaped[:0]
+	var encoded [utf8.UTFMax]byte
+
+	for idx := 0; idx < len(contents); {
+		c := contents[idx]
+
+		switch {
+		case c < ' ':
+			return nil, fmt.Errorf("invalid control character %q in string", c)
+		case c == '\\':
+			if idx+1 == len(contents) {
+				return nil, errors.New("unterminated escape in string")
+			}
+
+			if replacement, ok := unescapeChar(contents[idx+1]); ok {
+				unescaped = append(unescaped, replacement)
+				idx += 2
+
+				continue
+			}
+
+			if contents[idx+1] != 'u' {
+				return nil, fmt.Errorf("invalid escape '\\%c' in string", contents[idx+1])
+			}
+
+			r, size := decodeUnicodeEscape(contents[idx:])
+
+			if size == 0 {
+				return nil, errors.New("invalid unicode escape in string")
+			}
+
+			unescaped = append(unescaped, encoded[:utf8.EncodeRune(encoded[:], r)]...)
+			idx += size
+		case c < utf8.RuneSelf:
+			unescaped = append(unescaped, c)
+			idx++
+		default:
+			r, size := utf8.DecodeRune(contents[idx:])
+
+			if r == utf8.RuneError && size == 1 {
+				unescaped = append(unescaped, string(unicode.ReplacementChar)...)
+			} else {
+				unescaped = append(unescaped, contents[idx:idx+size]...)
+			}
+
+			idx += size
+		}
+	}
+
+	s.unescaped = unescaped
+
+	return unescaped, nil
+}
+
+// unescapeChar will return the character a single character escape in a JSON string stands for.
+func unescapeChar(c byte) (byte, bool) {
+	switch c {
+	case '"', '\\', '/':
+		return c, true
+	case 'b':
+		return '\b', true
+	case 'f':
+		return '\f', true
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	default:
+		return 0, false
+	}
+}
+
+// decodeUnicodeEscape will decode the \uXXXX escape at the start of the escaped string, along with the
+// low surrogate following it if it's a high surrogate. It returns the rune and the length of the escapes,
+// or a length of 0 if the escape is invalid. Unpaired surrogates decode to the replacement character.
+func decodeUnicodeEscape(escaped []byte) (rune, int) {
+	if len(escaped) < 6 {
+		return 0, 0
+	}
+
+	r := decodeHex(escaped[2:6])
+
+	if r < 0 {
+		return 0, 0
+	}
+
+	if !utf16.IsSurrogate(r) {
+		return r, 6
+	}
+
+	if len(escaped) >= 12 && escaped[6] == '\\' && escaped[7] == 'u' {
+		if decoded := utf16.DecodeRune(r, decodeHex(escaped[8:12])); decoded != unicode.ReplacementChar {
+			return decoded, 12
+		}
+	}
+
+	return unicode.ReplacementChar, 6
+}
+
+// decodeHex will decode the hexadecimal digits, returning -1 if any of them aren't valid.
+func decodeHex(digits []byte) rune {
+	var r rune
+
+	for _, c := range digits {
+		switch {
+		case c >= '0' && c <= '9':
+			r = r<<4 | rune(c-'0')
+		case c >= 'a' && c <= 'f':
+			r = r<<4 | rune(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			r = r<<4 | rune(c-'A'+10)
+		default:
+			return -1
+		}
+	}
+
+	return r
+}
+
+// readValue will read the next value, returning its raw JSON. The returned bytes are only valid until the
+// next value is read.
+func (s *jsonScanner) readValue() ([]byte, error) {
+	first, err := s.peek()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.value = s.value[:0]
+
+	switch first {
+	case '"', '{', '[':
+		err = s.readDelimited()
+	default:
+		err = s.readLiteral()
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return s.value, err
+}
+
+// readDelimited will read a string, object or array into the value buffer, up to its closing delimiter.
+// The buffered input is scanned a chunk at a time rather than a byte at a time.
+func (s *jsonScanner) readDelimited() error {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for {
+		if _, err := s.reader.Peek(1); err != nil {
+			return err
+		}
+
+		chunk, _ := s.reader.Peek(s.reader.Buffered())
+
+		for idx, c := range chunk {
+			switch {
+			case escaped:
+				escaped = false
+			case inString:
+				escaped = c == '\\'
+				inString = c != '"'
+			case c == '"':
+				inString = true
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+			}
+
+			if depth == 0 && !inString {
+				s.value = append(s.value, chunk[:idx+1]...)
+				_, err := s.reader.Discard(idx + 1)
+
+				return err
+			}
+		}
+
+		s.value = append(s.value, chunk...)
+
+		if _, err := s.reader.Discard(len(chunk)); err != nil {
+			return err
+		}
+	}
+}
+
+// readLiteral will read a number, boolean or null into the value buffer.
+func (s *jsonScanner) readLiteral() error {
+	for {
+		c, err := s.reader.ReadByte()
+
+		if err == io.EOF && len(s.value) > 0 {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if isDelimiter(c) {
+			return s.reader.UnreadByte()
+		}
+
+		s.value = append(s.value, c)
+	}
+}
+
+// expect will read the next byte that isn't whitespace and error if it isn't the expected one.
+func (s *jsonScanner) expect(expected byte) error {
+	c, err := s.next()
+
+	if err != nil {
+		return err
+	}
+
+	if c != expected {
+		return fmt.Errorf("expected '%c', got '%c'", expected, c)
+	}
+
+	return nil
+}
+
+// next will read the next byte that isn't whitespace.
+func (s *jsonScanner) next() (byte, error) {
+	for {
+		c, err := s.reader.ReadByte()
+
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		if err != nil || !isSpace(c) {
+			return c, err
+		}
+	}
+}
+
+// peek will return the next byte that isn't whitespace without consuming it.
+func (s *jsonScanner) peek() (byte, error) {
+	c, err := s.next()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return c, s.reader.UnreadByte()
+}
+
+// isSpace will return true if the byte is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// isDelimiter will return true if the byte ends a literal.
+func isDelimiter(c byte) bool {
+	return isSpace(c) || c == ',' || c == ':' || c == '}' || c == ']'
+}
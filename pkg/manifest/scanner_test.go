@@ -0,0 +1,165 @@
+package manifest
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONScanner(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedKeys   []string
+		expectedValues []string
+		expectedErr    bool
+	}{
+		{
+			name:           "empty object",
+			input:          ` { } `,
+			expectedKeys:   []string{},
+			expectedValues: []string{},
+		},
+		{
+			name:           "literals",
+			input:          `{"a": 1.5e3, "b":true,"c" : null}`,
+			expectedKeys:   []string{"a", "b", "c"},
+			expectedValues: []string{"1.5e3", "true", "null"},
+		},
+		{
+			name:           "strings with delimiters and escapes",
+			input:          `{"a\"b": "x}\\", "c": "[{\"}"}`,
+			expectedKeys:   []string{`a"b`, "c"},
+			expectedValues: []string{`"x}\\"`, `"[{\"}"`},
+		},
+		{
+			name:           "nested values",
+			input:          `{"a": {"b": ["}", {"c": []}]}, "d": [1, [2]]}`,
+			expectedKeys:   []string{"a", "d"},
+			expectedValues: []string{`{"b": ["}", {"c": []}]}`, `[1, [2]]`},
+		},
+		{
+			name:        "not an object",
+			input:       `[1]`,
+			expectedErr: true,
+		},
+		{
+			name:        "missing colon",
+			input:       `{"a" 1}`,
+			expectedErr: true,
+		},
+		{
+			name:        "missing comma",
+			input:       `{"a": 1 "b": 2}`,
+			expectedErr: true,
+		},
+		{
+			name:        "non-string key",
+			input:       `{1: 2}`,
+			expectedErr: true,
+		},
+		{
+			name:        "unterminated string",
+			input:       `{"a": "b`,
+			expectedErr: true,
+		},
+		{
+			name:        "unterminated object",
+			input:       `{"a": {"b": 1}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		scanner := &jsonScanner{reader: bufio.NewReaderSize(strings.NewReader(test.input), 16)}
+
+		keys := []string{}
+		values := []string{}
+		err := scanner.readObject(func(key string) error {
+			value, err := scanner.readValue()
+			keys = append(keys, key)
+			values = append(values, string(value))
+
+			return err
+		})
+
+		if test.expectedErr {
+			assert.Error(t, err, "%s: should error", test.name)
+			continue
+		}
+
+		assert.NoError(t, err, "%s: should have no error", test.name)
+		assert.Equal(t, test.expectedKeys, keys, "%s: keys should match", test.name)
+		assert.Equal(t, test.expectedValues, values, "%s: values should match", test.name)
+	}
+}
+
+func TestJSONScannerStrings(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectedErr bool
+	}{
+		{name: "plain", input: `"docker run jq"`, expected: "docker run jq"},
+		{name: "escapes", input: `"say \"hi\"\\\/\b\f\n\r\t"`, expected: "say \"hi\"\\/\b\f\n\r\t"},
+		{name: "unicode escape", input: `"caf\u00e9"`, expected: "café"},
+		{name: "surrogate pair", input: `"\ud83d\ude00"`, expected: "\U0001F600"},
+		{name: "unpaired surrogate", input: `"\ud83d!"`, expected: "\uFFFD!"},
+		{name: "utf-8", input: `"café"`, expected: "café"},
+		{name: "invalid utf-8", input: "\"a\xffb\"", expected: "a\uFFFDb"},
+		{name: "null", input: `null`, expected: "unchanged"},
+		{name: "control character", input: "\"a\nb\"", expectedErr: true},
+		{name: "invalid escape", input: `"\x41"`, expectedErr: true},
+		{name: "invalid unicode escape", input: `"\u00g9"`, expectedErr: true},
+		{name: "not a string", input: `12`, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		scanner := &jsonScanner{reader: bufio.NewReaderSize(strings.NewReader(test.input), 16)}
+
+		value := "unchanged"
+		err := scanner.readString(&value)
+
+		if test.expectedErr {
+			assert.Error(t, err, "%s: should error", test.name)
+			continue
+		}
+
+		assert.NoError(t, err, "%s: should have no error", test.name)
+		assert.Equal(t, test.expected, value, "%s: strings should match", test.name)
+	}
+}
+
+func TestJSONScannerStringArrays(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []string
+		expectedErr bool
+	}{
+		{name: "empty", input: `[ ]`, expected: []string{}},
+		{name: "strings", input: `["a", "b\"c" ,"d"]`, expected: []string{"a", `b"c`, "d"}},
+		{name: "null", input: `null`, expected: nil},
+		{name: "not strings", input: `[1]`, expectedErr: true},
+		{name: "missing comma", input: `["a" "b"]`, expectedErr: true},
+		{name: "not an array", input: `"a"`, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		scanner := &jsonScanner{reader: bufio.NewReaderSize(strings.NewReader(test.input), 16)}
+
+		values := []string{"unchanged"}
+		err := scanner.readStrings(&values)
+
+		if test.expectedErr {
+			assert.Error(t, err, "%s: should error", test.name)
+			continue
+		}
+
+		assert.NoError(t, err, "%s: should have no error", test.name)
+		assert.Equal(t, test.expected, values, "%s: strings should match", test.name)
+	}
+}
@@ -0,0 +1,241 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	// SchemaID is the identifier of the manifest JSON Schema.
+	SchemaID = "https://github.com/meowfaceman/conshim/schema/manifest.schema.json"
+
+	schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+	// semverPattern matches the versions accepted by semver.Parse.
+	semverPattern = `^v?\d+(\.\d+){0,2}(-.+)?$`
+)
+
+var (
+	// Strict enables validation of manifests read by ReadManifest against the manifest JSON Schema.
+	Strict = false
+
+	// schemaConstraints are keywords added to the generated schemas of struct fields, keyed by definition
+	// and JSON field name. They cover what can't be derived from the Go types.
+	schemaConstraints = map[string]map[string]interface{}{
+		"shim.command":               {"minLength": 1},
+		"shim.parameters":            {"items": map[string]interface{}{"type": "string", "pattern": `^[A-Za-z_][\w-]*$`}},
+		"shim.platforms":             {"items": map[string]interface{}{"type": "string", "pattern": `^[a-z0-9]+(/[a-z0-9]+(/[a-z0-9]+)?)?$`}},
+		"shim.digest":                {"pattern": "^sha256:[a-f0-9]+$"},
+		"shim.minConshimVersion":     {"pattern": semverPattern},
+		"manifest.minConshimVersion": {"pattern": semverPattern},
+		"changelogEntry.action": {
+			"enum": []string{ActionAdd, ActionUpdate, ActionRemove, ActionPin},
+		},
+	}
+
+	compileSchemasOnce sync.Once
+	manifestSchema     *jsonschema.Schema
+	shimSchema         *jsonschema.Schema
+	schemaErr          error
+)
+
+// Schema will generate the JSON Schema for manifests from the manifest types. The shim format is defined
+// under $defs/shim so that it can be referenced on its own.
+func Schema() map[string]interface{} {
+	defs := map[string]interface{}{}
+
+	root := structSchema("manifest", reflect.TypeOf(Manifest{}), defs)
+	root["$schema"] = schemaDraft
+	root["$id"] = SchemaID
+	root["title"] = "conshim manifest"
+	root["$defs"] = defs
+
+	return root
+}
+
+// SchemaJSON will return the manifest JSON Schema as indented JSON.
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling manifest schema")
+	}
+
+	return append(data, '\n'), nil
+}
+
+// typeSchema will generate the schema for a Go type. Structs are added to the definitions and referenced.
+func typeSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		// Nil slices are marshaled as null.
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		name := strings.ToLower(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := defs[name]; !ok {
+			// Reserve the definition first so that recursive types terminate.
+			defs[name] = nil
+			defs[name] = structSchema(name, t, defs)
+		}
+
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		panic(fmt.Sprintf("no schema for type %s", t))
+	}
+}
+
+// structSchema will generate the object schema for a struct from its JSON field tags. Fields that aren't
+// omitted when empty are required.
+func structSchema(name string, t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if tag == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		tagParts := strings.Split(tag, ",")
+		fieldName := tagParts[0]
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+
+		fieldSchema := typeSchema(field.Type, defs)
+		for keyword, value := range schemaConstraints[name+"."+fieldName] {
+			fieldSchema[keyword] = value
+		}
+
+		properties[fieldName] = fieldSchema
+
+		omitEmpty := false
+		for _, option := range tagParts[1:] {
+			omitEmpty = omitEmpty || option == "omitempty"
+		}
+
+		if !omitEmpty {
+			required = append(required, fieldName)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// compileSchemas will compile the manifest and shim schemas the first time they're needed.
+func compileSchemas() error {
+	compileSchemasOnce.Do(func() {
+		data, err := SchemaJSON()
+
+		if err != nil {
+			schemaErr = err
+			return
+		}
+
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(SchemaID, bytes.NewReader(data)); err != nil {
+			schemaErr = errors.Wrap(err, "error loading manifest schema")
+			return
+		}
+
+		if manifestSchema, err = compiler.Compile(SchemaID); err != nil {
+			schemaErr = errors.Wrap(err, "error compiling manifest schema")
+			return
+		}
+
+		if shimSchema, err = compiler.Compile(SchemaID + "#/$defs/shim"); err != nil {
+			schemaErr = errors.Wrap(err, "error compiling shim schema")
+		}
+	})
+
+	return schemaErr
+}
+
+// validateJSON will validate the raw JSON against the schema.
+func validateJSON(schema *jsonschema.Schema, data []byte) error {
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	if err := schema.Validate(value); err != nil {
+		validationErr := &jsonschema.ValidationError{}
+		if errors.As(err, &validationErr) {
+			return fmt.Errorf("%s", schemaErrorMessages(validationErr))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// schemaErrorMessages will flatten a validation error into one message per failed constraint.
+func schemaErrorMessages(err *jsonschema.ValidationError) string {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+
+		return fmt.Sprintf("%s: %s", location, err.Message)
+	}
+
+	messages := []string{}
+	for _, cause := range err.Causes {
+		messages = append(messages, schemaErrorMessages(cause))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ValidateShimJSON will validate a shim in JSON against the shim schema.
+func ValidateShimJSON(data []byte) error {
+	if err := compileSchemas(); err != nil {
+		return err
+	}
+
+	return validateJSON(shimSchema, data)
+}
+
+// ValidateManifestJSON will validate a manifest in JSON against the manifest schema.
+func ValidateManifestJSON(data []byte) error {
+	if err := compileSchemas(); err != nil {
+		return err
+	}
+
+	return validateJSON(manifestSchema, data)
+}
@@ -0,0 +1,138 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	defs, ok := schema["$defs"].(map[string]interface{})
+	assert.True(t, ok, "schema should have definitions")
+	assert.Contains(t, defs, "shim", "schema should define shims")
+	assert.Contains(t, defs, "changelogEntry", "schema should define changelog entries")
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Contains(t, properties, "shims", "schema should have shims")
+	assert.NotContains(t, properties, "Encoding", "fields that aren't serialized should not be in the schema")
+
+	shimProperties := defs["shim"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, field := range []string{"version", "parameters", "command", "platforms", "variants", "image", "digest"} {
+		assert.Contains(t, shimProperties, field, "shim schema should have field %s", field)
+	}
+
+	assert.NoError(t, compileSchemas(), "schema should compile")
+}
+
+func TestValidateShimJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		shim        string
+		expectedErr bool
+	}{
+		{
+			name: "valid shim",
+			shim: `{"version": "1", "parameters": ["x"], "command": "docker run {{x}}", "platforms": ["linux/amd64"]}`,
+		},
+		{
+			name: "null parameters",
+			shim: `{"version": "1", "parameters": null, "command": "docker run jq"}`,
+		},
+		{
+			name:        "missing command",
+			shim:        `{"version": "1", "parameters": []}`,
+			expectedErr: true,
+		},
+		{
+			name:        "unknown field",
+			shim:        `{"version": "1", "parameters": [], "command": "docker run jq", "comand": "typo"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "wrong type",
+			shim:        `{"version": 1, "parameters": [], "command": "docker run jq"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid platform",
+			shim:        `{"version": "1", "parameters": [], "command": "docker run jq", "platforms": ["Linux AMD64"]}`,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid digest",
+			shim:        `{"version": "1", "parameters": [], "command": "docker run jq", "digest": "md5:abc"}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateShimJSON([]byte(test.shim))
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal, got %v", test.name, err)
+	}
+}
+
+func TestReadManifestStrict(t *testing.T) {
+	defer func() {
+		Strict = false
+	}()
+
+	valid := CreateManifest(testSourceName)
+	valid.Encoding = EncodingJSON
+	valid.Shims["jq"] = shim.Shim{Version: "1", Command: "docker run jq"}
+	valid.AddChangelogEntry("jq", ActionAdd, "")
+
+	validBuffer := &bytes.Buffer{}
+	assert.NoError(t, valid.WriteManifest(validBuffer), "should be no error writing the manifest")
+
+	tests := []struct {
+		name        string
+		manifest    string
+		expectedErr bool
+	}{
+		{
+			name:     "valid manifest",
+			manifest: validBuffer.String(),
+		},
+		{
+			name:        "invalid shim",
+			manifest:    `{"source": "dummy", "version": "1.0.0", "shims": {"jq": {"version": "1", "parameters": [], "command": ""}}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "unknown manifest field",
+			manifest:    `{"source": "dummy", "version": "1.0.0", "shims": {}, "extra": true}`,
+			expectedErr: true,
+		},
+		{
+			name:        "missing shims",
+			manifest:    `{"source": "dummy", "version": "1.0.0"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid changelog action",
+			manifest:    `{"source": "dummy", "version": "1.0.0", "shims": {}, "changelog": [{"version": "1.0.0", "shim": "jq", "action": "delete", "timestamp": "2021-01-01T00:00:00Z"}]}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		data := []byte(test.manifest)
+		if json.Valid(data) {
+			data = append(append(append([]byte{}, envelopeMagic...), formatBytes[EncodingJSON]), data...)
+		}
+
+		Strict = false
+		_, err := ReadManifest(bytes.NewReader(data))
+		assert.NoError(t, err, "%s: should be no error reading the manifest without strict mode", test.name)
+
+		Strict = true
+		_, err = ReadManifest(bytes.NewReader(data))
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal in strict mode, got %v", test.name, err)
+	}
+}
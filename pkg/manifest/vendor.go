@@ -0,0 +1,185 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/pkg/errors"
+)
+
+// VendorState is how a vendored shim compares to its upstream.
+type VendorState string
+
+const (
+	// VendorUpToDate means neither the vendored shim nor the upstream shim changed since vendoring.
+	VendorUpToDate VendorState = "up to date"
+
+	// VendorBehind means the upstream shim changed since vendoring.
+	VendorBehind VendorState = "behind"
+
+	// VendorModified means the vendored shim was changed locally since vendoring.
+	VendorModified VendorState = "modified"
+
+	// VendorDiverged means both the vendored shim and the upstream shim changed since vendoring.
+	VendorDiverged VendorState = "behind and modified"
+
+	// VendorRemovedUpstream means the shim no longer exists in the upstream registry.
+	VendorRemovedUpstream VendorState = "removed upstream"
+
+	// VendorUnknownUpstream means the upstream registry couldn't be read.
+	VendorUnknownUpstream VendorState = "unknown upstream"
+)
+
+// UpstreamResolver returns the current manifest of the upstream registry with the given source.
+type UpstreamResolver func(source string) (*Manifest, error)
+
+// VendorStatus is the state of a vendored shim.
+type VendorStatus struct {
+	// Shim is the name of the vendored shim.
+	Shim string
+
+	// Upstream is the recorded provenance of the shim.
+	Upstream shim.Upstream
+
+	// State is how the shim compares to its upstream.
+	State VendorState
+
+	// UpstreamVersion is the current version of the upstream shim, if it still exists.
+	UpstreamVersion string
+
+	// Reason explains an unknown upstream.
+	Reason string
+}
+
+// String will return a string representation of the vendor status.
+func (v VendorStatus) String() string {
+	status := fmt.Sprintf("%s: %s (%s", v.Shim, v.State, v.Upstream)
+
+	if v.UpstreamVersion != "" && v.UpstreamVersion != v.Upstream.Version {
+		status += fmt.Sprintf(", now %s", v.UpstreamVersion)
+	}
+
+	status += ")"
+
+	if v.Reason != "" {
+		status += ": " + v.Reason
+	}
+
+	return status
+}
+
+// Checksum will return a checksum of the shim's contents that ignores its provenance and any differences
+// that don't survive serialization.
+func Checksum(s shim.Shim) string {
+	normalized := normalizeShim(s)
+	normalized.Upstream = nil
+
+	// Marshaling a struct of strings, lists and maps can't fail, and maps are marshaled in key order.
+	data, _ := json.Marshal(normalized)
+	hash := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// VendorShim will copy the shim from the upstream manifest into this manifest under the local name,
+// recording its provenance. The changelog action for the shim is returned. Existing shims are only
+// replaced if overwrite is true.
+func (m *Manifest) VendorShim(upstream *Manifest, upstreamName, localName string, overwrite bool) (string, error) {
+	upstreamShim, ok := upstream.Shims[upstreamName]
+
+	if !ok {
+		return "", fmt.Errorf("shim '%s' does not exist in upstream registry '%s'", upstreamName, upstream.Source)
+	}
+
+	if upstream.Source == m.Source {
+		return "", fmt.Errorf("can't vendor shim '%s' from the manifest's own registry", upstreamName)
+	}
+
+	action := ActionAdd
+	if _, exists := m.Shims[localName]; exists {
+		if !overwrite {
+			return "", fmt.Errorf("shim '%s' already exists in the manifest", localName)
+		}
+
+		action = ActionUpdate
+	}
+
+	vendored := upstreamShim
+	vendored.Upstream = &shim.Upstream{
+		Source:          upstream.Source,
+		Name:            upstreamName,
+		Version:         upstreamShim.Version,
+		ManifestVersion: upstream.Version,
+		Checksum:        Checksum(upstreamShim),
+	}
+
+	m.Shims[localName] = vendored
+
+	return action, nil
+}
+
+// VendorStatuses will compare every vendored shim in the manifest against its upstream, sorted by shim
+// name. Each upstream registry is resolved once.
+func (m *Manifest) VendorStatuses(resolve UpstreamResolver) []VendorStatus {
+	upstreams := map[string]*Manifest{}
+	upstreamErrs := map[string]error{}
+	statuses := []VendorStatus{}
+
+	for _, shimName := range shimNames(m) {
+		local := m.Shims[shimName]
+		if local.Upstream == nil {
+			continue
+		}
+
+		status := VendorStatus{Shim: shimName, Upstream: *local.Upstream}
+		source := local.Upstream.Source
+
+		if _, resolved := upstreams[source]; !resolved && upstreamErrs[source] == nil {
+			upstream, err := resolve(source)
+
+			if err != nil {
+				upstreamErrs[source] = errors.Wrapf(err, "error reading upstream registry '%s'", source)
+			} else {
+				upstreams[source] = upstream
+			}
+		}
+
+		if err := upstreamErrs[source]; err != nil {
+			status.State = VendorUnknownUpstream
+			status.Reason = err.Error()
+			statuses = append(statuses, status)
+
+			continue
+		}
+
+		upstreamShim, ok := upstreams[source].Shims[local.Upstream.Name]
+		if !ok {
+			status.State = VendorRemovedUpstream
+			statuses = append(statuses, status)
+
+			continue
+		}
+
+		status.UpstreamVersion = upstreamShim.Version
+		behind := Checksum(upstreamShim) != local.Upstream.Checksum
+		modified := Checksum(local) != local.Upstream.Checksum
+
+		switch {
+		case behind && modified:
+			status.State = VendorDiverged
+		case behind:
+			status.State = VendorBehind
+		case modified:
+			status.State = VendorModified
+		default:
+			status.State = VendorUpToDate
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
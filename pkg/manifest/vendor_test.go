@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVendorShim(t *testing.T) {
+	upstream := CreateManifest("upstream")
+	upstream.Version = "2.1.0"
+	upstream.Shims["jq"] = shim.Shim{Version: "1.6", Command: "docker run jq"}
+
+	m := CreateManifest(testSourceName)
+
+	action, err := m.VendorShim(upstream, "jq", "jq", false)
+	assert.NoError(t, err, "vendoring should not error")
+	assert.Equal(t, ActionAdd, action, "new shims should be added")
+	assert.Equal(t, &shim.Upstream{
+		Source:          "upstream",
+		Name:            "jq",
+		Version:         "1.6",
+		ManifestVersion: "2.1.0",
+		Checksum:        Checksum(upstream.Shims["jq"]),
+	}, m.Shims["jq"].Upstream, "provenance should be recorded")
+	assert.Equal(t, "docker run jq", m.Shims["jq"].Command, "the shim should be copied")
+	assert.Nil(t, upstream.Shims["jq"].Upstream, "the upstream shim should not be changed")
+
+	_, err = m.VendorShim(upstream, "jq", "jq", false)
+	assert.Error(t, err, "vendoring over an existing shim should error")
+
+	action, err = m.VendorShim(upstream, "jq", "jq", true)
+	assert.NoError(t, err, "re-vendoring should not error")
+	assert.Equal(t, ActionUpdate, action, "existing shims should be updated")
+
+	_, err = m.VendorShim(upstream, "yq", "yq", false)
+	assert.Error(t, err, "vendoring a missing shim should error")
+
+	_, err = m.VendorShim(m, "jq", "jq2", false)
+	assert.Error(t, err, "vendoring from the manifest's own registry should error")
+}
+
+func TestVendorStatuses(t *testing.T) {
+	upstream := CreateManifest("upstream")
+	for _, shimName := range []string{"current", "behind", "modified", "diverged", "removed"} {
+		upstream.Shims[shimName] = shim.Shim{Version: "1", Command: "docker run " + shimName}
+	}
+
+	m := CreateManifest(testSourceName)
+	m.Shims["local"] = shim.Shim{Command: "docker run local"}
+	for shimName := range upstream.Shims {
+		_, err := m.VendorShim(upstream, shimName, shimName, false)
+		assert.NoError(t, err, "vendoring should not error")
+	}
+
+	gone := CreateManifest("gone")
+	gone.Shims["old"] = shim.Shim{Version: "1", Command: "docker run old"}
+	_, err := m.VendorShim(gone, "old", "old", false)
+	assert.NoError(t, err, "vendoring should not error")
+
+	upstream.Shims["behind"] = shim.Shim{Version: "2", Command: "docker run behind:2"}
+	upstream.Shims["diverged"] = shim.Shim{Version: "2", Command: "docker run diverged:2"}
+	delete(upstream.Shims, "removed")
+
+	modified := m.Shims["modified"]
+	modified.Description = "changed locally"
+	m.Shims["modified"] = modified
+
+	diverged := m.Shims["diverged"]
+	diverged.Tags = []string{"local"}
+	m.Shims["diverged"] = diverged
+
+	resolveCount := 0
+	resolve := func(source string) (*Manifest, error) {
+		resolveCount++
+
+		if source == upstream.Source {
+			return upstream, nil
+		}
+
+		return nil, fmt.Errorf("no registry '%s'", source)
+	}
+
+	states := map[string]VendorState{}
+	for _, status := range m.VendorStatuses(resolve) {
+		states[status.Shim] = status.State
+	}
+
+	assert.Equal(t, map[string]VendorState{
+		"behind":   VendorBehind,
+		"current":  VendorUpToDate,
+		"diverged": VendorDiverged,
+		"modified": VendorModified,
+		"old":      VendorUnknownUpstream,
+		"removed":  VendorRemovedUpstream,
+	}, states, "vendor states should match")
+	assert.Equal(t, 2, resolveCount, "each upstream should only be resolved once")
+}
+
+func TestChecksum(t *testing.T) {
+	s := shim.Shim{Version: "1", Command: "docker run jq"}
+	withEmptyLists := shim.Shim{Version: "1", Command: "docker run jq", Parameters: []string{}, Tags: []string{}}
+	withUpstream := shim.Shim{Version: "1", Command: "docker run jq", Upstream: &shim.Upstream{Source: "upstream"}}
+
+	assert.Equal(t, Checksum(s), Checksum(withEmptyLists), "empty lists should not change the checksum")
+	assert.Equal(t, Checksum(s), Checksum(withUpstream), "provenance should not change the checksum")
+	assert.NotEqual(t, Checksum(s), Checksum(shim.Shim{Version: "2", Command: "docker run jq"}), "contents should change the checksum")
+}
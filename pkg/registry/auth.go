@@ -0,0 +1,185 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultSSHUser is the user for SSH registries whose URL doesn't name one.
+	defaultSSHUser = "git"
+
+	// defaultHTTPUser is the username sent with tokens when neither the auth nor the URL name one. Git
+	// hosts ignore the username of token auth, but it can't be empty.
+	defaultHTTPUser = "conshim"
+)
+
+// authMethod will return how to authenticate with the git registry at the URL, or nil if it doesn't need
+// authentication. SSH registries use the key file if one is given and the SSH agent otherwise, and always
+// check host keys against known_hosts. HTTP(S) registries use the token from the environment or git's
+// credential helpers if they're asked for.
+func authMethod(ctx context.Context, url string, auth manifest.Auth) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing registry URL '%s'", url)
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		return sshAuth(endpoint, auth)
+	case "http", "https":
+		basicAuth, err := httpAuth(ctx, endpoint, auth)
+
+		// A nil *BasicAuth would otherwise be a non-nil AuthMethod.
+		if err != nil || basicAuth == nil {
+			return nil, err
+		}
+
+		return basicAuth, nil
+	default:
+		return nil, nil
+	}
+}
+
+// sshAuth will return the SSH auth for the endpoint.
+func sshAuth(endpoint *transport.Endpoint, auth manifest.Auth) (transport.AuthMethod, error) {
+	user := endpoint.User
+	if user == "" {
+		user = defaultSSHUser
+	}
+
+	knownHosts := []string{}
+	if auth.KnownHostsFile != "" {
+		knownHosts = append(knownHosts, auth.KnownHostsFile)
+	}
+
+	hostKeyCallback, err := gitssh.NewKnownHostsCallback(knownHosts...)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading known_hosts for SSH registry")
+	}
+
+	if auth.SSHKeyFile != "" {
+		passphrase := ""
+		if auth.SSHKeyPassphraseEnv != "" {
+			passphrase = os.Getenv(auth.SSHKeyPassphraseEnv)
+		}
+
+		keys, keyErr := gitssh.NewPublicKeysFromFile(user, auth.SSHKeyFile, passphrase)
+
+		if keyErr != nil {
+			return nil, errors.Wrapf(keyErr, "error reading SSH key '%s'", auth.SSHKeyFile)
+		}
+
+		keys.HostKeyCallback = hostKeyCallback
+
+		return keys, nil
+	}
+
+	agentAuth, err := gitssh.NewSSHAgentAuth(user)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to the SSH agent, start one or give an SSH key file")
+	}
+
+	agentAuth.HostKeyCallback = hostKeyCallback
+
+	return agentAuth, nil
+}
+
+// httpAuth will return the basic auth for the endpoint, or nil if it has no credentials configured, in
+// which case credentials in the URL itself are still used.
+func httpAuth(ctx context.Context, endpoint *transport.Endpoint, auth manifest.Auth) (*githttp.BasicAuth, error) {
+	username := auth.Username
+	if username == "" {
+		username = endpoint.User
+	}
+
+	switch {
+	case auth.TokenEnv != "":
+		token := os.Getenv(auth.TokenEnv)
+
+		if token == "" {
+			return nil, fmt.Errorf("registry token environment variable '%s' is not set", auth.TokenEnv)
+		}
+
+		if username == "" {
+			username = defaultHTTPUser
+		}
+
+		return &githttp.BasicAuth{Username: username, Password: token}, nil
+	case auth.CredentialHelper:
+		return credentialHelperAuth(ctx, endpoint, username)
+	default:
+		return nil, nil
+	}
+}
+
+// credentialHelperAuth will ask git's credential helpers for the credentials of the endpoint, as git itself
+// would when cloning it. Prompting is disabled, so missing credentials are an error rather than a hang.
+func credentialHelperAuth(ctx context.Context, endpoint *transport.Endpoint, username string) (*githttp.BasicAuth, error) {
+	host := endpoint.Host
+	if endpoint.Port != 0 {
+		host += ":" + strconv.Itoa(endpoint.Port)
+	}
+
+	request := fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n", endpoint.Protocol, host, strings.TrimPrefix(endpoint.Path, "/"))
+	if username != "" {
+		request += fmt.Sprintf("username=%s\n", username)
+	}
+
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(request + "\n")
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting credentials for '%s' from git credential helpers: %s", host, strings.TrimSpace(stderr.String()))
+	}
+
+	credentials := &githttp.BasicAuth{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value := splitCredentialLine(scanner.Text())
+
+		switch key {
+		case "username":
+			credentials.Username = value
+		case "password":
+			credentials.Password = value
+		}
+	}
+
+	if credentials.Password == "" {
+		return nil, fmt.Errorf("git credential helpers have no credentials for '%s'", host)
+	}
+
+	return credentials, nil
+}
+
+// splitCredentialLine will split a "key=value" line of the git credential protocol.
+func splitCredentialLine(line string) (string, string) {
+	if idx := strings.Index(line, "="); idx >= 0 {
+		return line[:idx], line[idx+1:]
+	}
+
+	return line, ""
+}
@@ -0,0 +1,394 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	testUsername = "registry-user"
+	testToken    = "registry-token"
+	testTokenEnv = "CONSHIM_TEST_REGISTRY_TOKEN"
+)
+
+func TestGitBackendHTTPAuth(t *testing.T) {
+	url := startHTTPGitServer(t)
+
+	tests := []struct {
+		name        string
+		auth        manifest.Auth
+		env         map[string]string
+		expectedErr bool
+	}{
+		{
+			name:        "no credentials",
+			expectedErr: true,
+		},
+		{
+			name: "token from the environment",
+			auth: manifest.Auth{Username: testUsername, TokenEnv: testTokenEnv},
+			env:  map[string]string{testTokenEnv: testToken},
+		},
+		{
+			name:        "wrong token",
+			auth:        manifest.Auth{Username: testUsername, TokenEnv: testTokenEnv},
+			env:         map[string]string{testTokenEnv: "wrong"},
+			expectedErr: true,
+		},
+		{
+			name:        "unset token",
+			auth:        manifest.Auth{Username: testUsername, TokenEnv: testTokenEnv},
+			expectedErr: true,
+		},
+		{
+			name: "git credential helper",
+			auth: manifest.Auth{CredentialHelper: true},
+			env: map[string]string{
+				"GIT_CONFIG_COUNT":   "1",
+				"GIT_CONFIG_KEY_0":   "credential.helper",
+				"GIT_CONFIG_VALUE_0": "!f() { echo username=" + testUsername + "; echo password=" + testToken + "; }; f",
+			},
+		},
+		{
+			name: "git credential helper without credentials",
+			auth: manifest.Auth{CredentialHelper: true},
+			env: map[string]string{
+				"GIT_CONFIG_COUNT":   "1",
+				"GIT_CONFIG_KEY_0":   "credential.helper",
+				"GIT_CONFIG_VALUE_0": "!true",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		unsetEnv := setTestEnv(t, test.env)
+
+		backend := &GitBackend{URL: url, Path: ManifestFilename, Auth: test.auth}
+		m, err := backend.Fetch(context.Background())
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal, got %v", test.name, err)
+		if !test.expectedErr {
+			assert.Equal(t, testSourceName, m.Source, "%s: the manifest should be read", test.name)
+		}
+
+		unsetEnv()
+	}
+}
+
+func TestHTTPBackendAuth(t *testing.T) {
+	manifestFile := filepath.Join(t.TempDir(), ManifestFilename)
+	writeTestManifest(t, manifestFile)
+
+	server := httptest.NewServer(requireBasicAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, manifestFile)
+	})))
+	defer server.Close()
+
+	backend := &HTTPBackend{URL: server.URL + "/" + ManifestFilename}
+	_, err := backend.Fetch(context.Background())
+	assert.Error(t, err, "fetching without credentials should error")
+
+	unsetEnv := setTestEnv(t, map[string]string{testTokenEnv: testToken})
+	defer unsetEnv()
+
+	backend.Auth = manifest.Auth{Username: testUsername, TokenEnv: testTokenEnv}
+	m, err := backend.Fetch(context.Background())
+	assert.NoError(t, err, "fetching with credentials should not error")
+	assert.Equal(t, testSourceName, m.Source, "the manifest should be read")
+}
+
+func TestGitBackendSSHAuth(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	assert.NoError(t, os.Mkdir(repoDir, 0755), "should be no error creating the repository")
+	writeTestManifest(t, filepath.Join(repoDir, ManifestFilename))
+	commitTestRepo(t, repoDir)
+
+	clientKey, clientKeyFile := generateTestKey(t, dir, "client")
+	otherKey, otherKeyFile := generateTestKey(t, dir, "other")
+	hostKey, _ := generateTestKey(t, dir, "host")
+
+	addr := startSSHGitServer(t, hostKey, clientKey.PublicKey())
+	url := "ssh://git@" + addr + repoDir
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	writeKnownHosts(t, knownHostsFile, addr, hostKey.PublicKey())
+
+	wrongKnownHostsFile := filepath.Join(dir, "wrong_known_hosts")
+	writeKnownHosts(t, wrongKnownHostsFile, addr, otherKey.PublicKey())
+
+	tests := []struct {
+		name        string
+		auth        manifest.Auth
+		agentKey    *rsa.PrivateKey
+		expectedErr bool
+	}{
+		{
+			name: "key file",
+			auth: manifest.Auth{SSHKeyFile: clientKeyFile, KnownHostsFile: knownHostsFile},
+		},
+		{
+			name:        "unauthorized key file",
+			auth:        manifest.Auth{SSHKeyFile: otherKeyFile, KnownHostsFile: knownHostsFile},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown host key",
+			auth:        manifest.Auth{SSHKeyFile: clientKeyFile, KnownHostsFile: wrongKnownHostsFile},
+			expectedErr: true,
+		},
+		{
+			name:     "ssh agent",
+			auth:     manifest.Auth{KnownHostsFile: knownHostsFile},
+			agentKey: readTestKey(t, clientKeyFile),
+		},
+		{
+			name:        "ssh agent without the key",
+			auth:        manifest.Auth{KnownHostsFile: knownHostsFile},
+			agentKey:    readTestKey(t, otherKeyFile),
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		unsetEnv := func() {}
+		if test.agentKey != nil {
+			unsetEnv = setTestEnv(t, map[string]string{"SSH_AUTH_SOCK": startTestAgent(t, test.agentKey)})
+		}
+
+		backend := &GitBackend{URL: url, Path: ManifestFilename, Auth: test.auth}
+		m, err := backend.Fetch(context.Background())
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal, got %v", test.name, err)
+		if !test.expectedErr {
+			assert.Equal(t, testSourceName, m.Source, "%s: the manifest should be read", test.name)
+		}
+
+		unsetEnv()
+	}
+}
+
+// startHTTPGitServer will serve a registry repository over git's smart HTTP protocol behind basic auth,
+// returning the URL of the repository.
+func startHTTPGitServer(t *testing.T) string {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is needed to serve repositories over HTTP")
+	}
+
+	root := t.TempDir()
+	repoDir := filepath.Join(root, "repo")
+	assert.NoError(t, os.Mkdir(repoDir, 0755), "should be no error creating the repository")
+	writeTestManifest(t, filepath.Join(repoDir, ManifestFilename))
+	commitTestRepo(t, repoDir)
+
+	server := httptest.NewServer(requireBasicAuth(&cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL + "/repo"
+}
+
+// requireBasicAuth will only pass requests with the test credentials to the handler.
+func requireBasicAuth(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != testUsername || password != testToken {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// startSSHGitServer will serve repositories by path over SSH to clients with the authorized key, running
+// git-upload-pack as a git host would, and return the address of the server.
+func startSSHGitServer(t *testing.T, hostKey ssh.Signer, authorizedKey ssh.PublicKey) string {
+	uploadPack, err := exec.LookPath("git-upload-pack")
+	if err != nil {
+		t.Skip("git-upload-pack is needed to serve repositories over SSH")
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorizedKey.Marshal()) {
+				return nil, assert.AnError
+			}
+
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "should be no error listening for SSH")
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+
+			go serveSSHConn(conn, config, uploadPack)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// serveSSHConn will run the git-upload-pack commands of the SSH connection.
+func serveSSHConn(conn net.Conn, config *ssh.ServerConfig, uploadPack string) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			defer channel.Close()
+
+			for request := range channelRequests {
+				if request.Type != "exec" {
+					_ = request.Reply(false, nil)
+					continue
+				}
+
+				// The payload is the command as a length-prefixed string, such as "git-upload-pack '/repo'".
+				command := string(request.Payload[4:])
+				repoPath := strings.Trim(strings.TrimPrefix(command, "git-upload-pack "), "'")
+				_ = request.Reply(true, nil)
+
+				cmd := exec.Command(uploadPack, repoPath)
+				cmd.Stdin = channel
+				cmd.Stdout = channel
+				cmd.Stderr = channel.Stderr()
+
+				status := make([]byte, 4)
+				if runErr := cmd.Run(); runErr != nil {
+					binary.BigEndian.PutUint32(status, 1)
+				}
+
+				_, _ = channel.SendRequest("exit-status", false, status)
+
+				return
+			}
+		}()
+	}
+}
+
+// generateTestKey will generate an RSA key and write it to a PEM file in the directory.
+func generateTestKey(t *testing.T, dir, name string) (ssh.Signer, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err, "should be no error generating a key")
+
+	keyFile := filepath.Join(dir, name)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	assert.NoError(t, ioutil.WriteFile(keyFile, keyPEM, 0600), "should be no error writing the key")
+
+	signer, err := ssh.NewSignerFromKey(key)
+	assert.NoError(t, err, "should be no error creating a signer")
+
+	return signer, keyFile
+}
+
+// readTestKey will read an RSA key written by generateTestKey.
+func readTestKey(t *testing.T, keyFile string) *rsa.PrivateKey {
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	assert.NoError(t, err, "should be no error reading the key")
+
+	block, _ := pem.Decode(keyPEM)
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	assert.NoError(t, err, "should be no error parsing the key")
+
+	return key
+}
+
+// writeKnownHosts will write a known_hosts file trusting the host key for the address.
+func writeKnownHosts(t *testing.T, path, addr string, hostKey ssh.PublicKey) {
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey) + "\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte(line), 0600), "should be no error writing known_hosts")
+}
+
+// startTestAgent will serve an SSH agent holding the key on a socket, and return the socket's path.
+func startTestAgent(t *testing.T, key *rsa.PrivateKey) string {
+	keyring := agent.NewKeyring()
+	assert.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: key}), "should be no error adding the key to the agent")
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err, "should be no error listening for the agent")
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+
+			go func() { _ = agent.ServeAgent(keyring, conn) }()
+		}
+	}()
+
+	return socket
+}
+
+// setTestEnv will set the environment variables, returning a function that restores them.
+func setTestEnv(t *testing.T, env map[string]string) func() {
+	previous := map[string]*string{}
+
+	for key, value := range env {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+
+		assert.NoError(t, os.Setenv(key, value), "should be no error setting %s", key)
+	}
+
+	return func() {
+		for key, old := range previous {
+			if old == nil {
+				assert.NoError(t, os.Unsetenv(key), "should be no error unsetting %s", key)
+			} else {
+				assert.NoError(t, os.Setenv(key, *old), "should be no error restoring %s", key)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/pkg/errors"
+)
+
+// Backend fetches the manifest of a registry from wherever the registry is hosted.
+type Backend interface {
+	// Fetch will fetch the current manifest of the registry.
+	Fetch(ctx context.Context) (*manifest.Manifest, error)
+}
+
+// NewBackend will select the backend for the registry URL by its scheme:
+//
+//   - file:// URLs and paths that exist locally are read from the local filesystem.
+//   - http:// and https:// URLs of a .br file are downloaded directly.
+//   - Everything else is treated as a git repository.
+//
+// Only git registries can be pinned to a ref, and HTTP(S) registries can't have a manifest path since
+// their URL is the manifest itself. Local registries don't authenticate. In offline mode, only local
+// registries can be read.
+func NewBackend(registryURL string, options Options) (Backend, error) {
+	if strings.HasPrefix(registryURL, fileScheme) {
+		return newLocalBackend(strings.TrimPrefix(registryURL, fileScheme), options)
+	}
+
+	if _, err := os.Stat(registryURL); err == nil {
+		return newLocalBackend(registryURL, options)
+	}
+
+	if parsed, err := url.Parse(registryURL); err == nil && isHTTPManifestURL(parsed) {
+		if options.Ref != "" || options.Path != "" {
+			return nil, fmt.Errorf("registry '%s' is a manifest URL, so it can't have a ref or a path", registryURL)
+		}
+
+		if Offline {
+			return nil, errors.Wrapf(ErrOffline, "can't fetch registry '%s'", registryURL)
+		}
+
+		return &HTTPBackend{URL: registryURL, Auth: options.Auth}, nil
+	}
+
+	if Offline {
+		return nil, errors.Wrapf(ErrOffline, "can't fetch registry '%s'", registryURL)
+	}
+
+	return &GitBackend{URL: mungeURL(registryURL), Ref: options.Ref, Path: options.manifestPath(), Auth: options.Auth}, nil
+}
+
+// newLocalBackend will create a backend for a local registry.
+func newLocalBackend(localPath string, options Options) (Backend, error) {
+	if options.Ref != "" {
+		return nil, fmt.Errorf("can't pin registry '%s' to ref '%s', only git registries can be pinned", localPath, options.Ref)
+	}
+
+	if !options.Auth.IsZero() {
+		return nil, fmt.Errorf("registry '%s' is local, so it can't have authentication settings", localPath)
+	}
+
+	if options.Path != "" {
+		localPath = filepath.Join(localPath, filepath.FromSlash(options.manifestPath()))
+	}
+
+	return &LocalBackend{Path: localPath}, nil
+}
+
+// isHTTPManifestURL will return true if the URL is an HTTP(S) URL of a manifest file.
+func isHTTPManifestURL(parsed *url.URL) bool {
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && path.Ext(parsed.Path) == path.Ext(ManifestFilename)
+}
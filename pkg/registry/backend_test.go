@@ -0,0 +1,287 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSourceName = "test-registry"
+)
+
+func TestNewBackend(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected Backend
+	}{
+		{
+			name:     "file URL",
+			url:      "file:///srv/registry",
+			expected: &LocalBackend{Path: "/srv/registry"},
+		},
+		{
+			name:     "existing local path",
+			url:      dir,
+			expected: &LocalBackend{Path: dir},
+		},
+		{
+			name:     "https manifest URL",
+			url:      "https://artifacts.example.com/shims/manifest.br",
+			expected: &HTTPBackend{URL: "https://artifacts.example.com/shims/manifest.br"},
+		},
+		{
+			name:     "https repository URL",
+			url:      "https://github.com/some/repo",
+			expected: &GitBackend{URL: "https://github.com/some/repo", Path: ManifestFilename},
+		},
+		{
+			name:     "repository without a schema",
+			url:      "github.com/some/repo",
+			expected: &GitBackend{URL: "https://github.com/some/repo", Path: ManifestFilename},
+		},
+		{
+			name:     "ssh repository",
+			url:      "git@github.com:some/repo",
+			expected: &GitBackend{URL: "git@github.com:some/repo", Path: ManifestFilename},
+		},
+	}
+
+	for _, test := range tests {
+		backend, err := NewBackend(test.url, Options{})
+
+		assert.NoError(t, err, "%s: should be no error selecting the backend", test.name)
+		assert.Equal(t, test.expected, backend, "%s: backends should match", test.name)
+	}
+
+	backend, err := NewBackend("github.com/some/repo", Options{Ref: "v2"})
+	assert.NoError(t, err, "should be no error pinning a git registry")
+	assert.Equal(t, &GitBackend{URL: "https://github.com/some/repo", Ref: "v2", Path: ManifestFilename}, backend, "git backends should be pinned")
+
+	_, err = NewBackend(dir, Options{Ref: "v2"})
+	assert.Error(t, err, "pinning a local registry should error")
+
+	_, err = NewBackend(dir, Options{Auth: manifest.Auth{TokenEnv: "TOKEN"}})
+	assert.Error(t, err, "authenticating a local registry should error")
+
+	backend, err = NewBackend("git@github.com:some/repo", Options{Auth: manifest.Auth{SSHKeyFile: "id_rsa"}})
+	assert.NoError(t, err, "should be no error authenticating a git registry")
+	assert.Equal(t, &GitBackend{URL: "git@github.com:some/repo", Path: ManifestFilename, Auth: manifest.Auth{SSHKeyFile: "id_rsa"}}, backend, "git backends should authenticate")
+
+	backend, err = NewBackend("github.com/some/repo", Options{Path: "registries/team"})
+	assert.NoError(t, err, "should be no error setting the path of a git registry")
+	assert.Equal(t, &GitBackend{URL: "https://github.com/some/repo", Path: "registries/team/manifest.br"}, backend, "git backends should use the path")
+
+	backend, err = NewBackend(dir, Options{Path: "registries/team/team.br"})
+	assert.NoError(t, err, "should be no error setting the path of a local registry")
+	assert.Equal(t, &LocalBackend{Path: filepath.Join(dir, "registries", "team", "team.br")}, backend, "local backends should use the path")
+
+	_, err = NewBackend("https://artifacts.example.com/manifest.br", Options{Path: "team"})
+	assert.Error(t, err, "setting the path of an HTTP registry should error")
+}
+
+func TestManifestPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: "manifest.br"},
+		{path: "registries/team", expected: "registries/team/manifest.br"},
+		{path: "/registries/team/", expected: "registries/team/manifest.br"},
+		{path: "registries/team/custom.br", expected: "registries/team/custom.br"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Options{Path: test.path}.manifestPath(), "%s: paths should match", test.path)
+	}
+}
+
+func TestLocalBackend(t *testing.T) {
+	dir := t.TempDir()
+	expected := writeTestManifest(t, filepath.Join(dir, ManifestFilename))
+
+	for _, path := range []string{dir, filepath.Join(dir, ManifestFilename)} {
+		m, err := (&LocalBackend{Path: path}).Fetch(context.Background())
+
+		assert.NoError(t, err, "%s: fetching should not error", path)
+		assert.Equal(t, expected.Shims, m.Shims, "%s: shims should match", path)
+	}
+
+	_, err := (&LocalBackend{Path: filepath.Join(dir, "missing")}).Fetch(context.Background())
+	assert.Error(t, err, "fetching a missing registry should error")
+}
+
+func TestHTTPBackend(t *testing.T) {
+	data := &bytes.Buffer{}
+	expected := manifest.CreateManifest(testSourceName)
+	expected.Shims["jq"] = shim.Shim{Version: "1", Command: "docker run jq"}
+	assert.NoError(t, expected.WriteManifest(data), "should be no error writing the manifest")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/shims/manifest.br" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(data.Bytes())
+	}))
+	defer server.Close()
+
+	backend, err := NewBackend(server.URL+"/shims/manifest.br", Options{})
+	assert.NoError(t, err, "should be no error selecting the backend")
+
+	m, err := backend.Fetch(context.Background())
+	assert.NoError(t, err, "fetching should not error")
+	assert.Equal(t, expected.Shims, m.Shims, "shims should match")
+
+	_, err = (&HTTPBackend{URL: server.URL + "/missing.br"}).Fetch(context.Background())
+	assert.Error(t, err, "fetching a missing manifest should error")
+}
+
+func TestGitBackend(t *testing.T) {
+	dir := t.TempDir()
+	expected := writeTestManifest(t, filepath.Join(dir, ManifestFilename))
+	firstCommit := commitTestRepo(t, dir)
+
+	m, err := (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching should not error")
+	assert.Equal(t, expected.Shims, m.Shims, "shims should match")
+	assert.Equal(t, firstCommit, m.Origin.Commit, "the commit should be recorded")
+
+	_, err = (&GitBackend{URL: filepath.Join(dir, "missing")}).Fetch(context.Background())
+	assert.Error(t, err, "fetching a missing repository should error")
+}
+
+func TestGitBackendPath(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "registries", "team"), 0700), "should be no error creating the registry directory")
+
+	expected := writeTestManifest(t, filepath.Join(dir, "registries", "team", ManifestFilename))
+	commitTestRepo(t, dir)
+
+	m, err := (&GitBackend{URL: dir, Path: "registries/team/manifest.br"}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching should not error")
+	assert.Equal(t, expected.Shims, m.Shims, "shims should match")
+
+	_, err = (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.Error(t, err, "fetching a registry without a root manifest should error")
+}
+
+func TestGitBackendRefs(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, filepath.Join(dir, ManifestFilename))
+	firstCommit := commitTestRepo(t, dir)
+
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(t, err, "should be no error opening the repository")
+
+	_, err = repo.CreateTag("v1", plumbing.NewHash(firstCommit), nil)
+	assert.NoError(t, err, "should be no error tagging")
+
+	head, err := repo.Head()
+	assert.NoError(t, err, "should be no error getting HEAD")
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/stable", plumbing.NewHash(firstCommit))), "should be no error branching")
+
+	m := manifest.CreateManifest(testSourceName)
+	m.Shims["yq"] = shim.Shim{Version: "2", Command: "docker run yq"}
+	file, err := os.Create(filepath.Join(dir, ManifestFilename))
+	assert.NoError(t, err, "should be no error creating the manifest")
+	assert.NoError(t, m.WriteManifest(file), "should be no error writing the manifest")
+	assert.NoError(t, file.Close(), "should be no error closing the manifest")
+	secondCommit := commitTestRepo(t, dir)
+
+	tests := []struct {
+		name           string
+		ref            string
+		expectedCommit string
+		expectedShim   string
+		expectedErr    bool
+	}{
+		{name: "default branch", ref: "", expectedCommit: secondCommit, expectedShim: "yq"},
+		{name: "default branch by name", ref: head.Name().Short(), expectedCommit: secondCommit, expectedShim: "yq"},
+		{name: "branch", ref: "stable", expectedCommit: firstCommit, expectedShim: "jq"},
+		{name: "tag", ref: "v1", expectedCommit: firstCommit, expectedShim: "jq"},
+		{name: "commit", ref: firstCommit, expectedCommit: firstCommit, expectedShim: "jq"},
+		{name: "short commit", ref: firstCommit[:8], expectedCommit: firstCommit, expectedShim: "jq"},
+		{name: "missing ref", ref: "missing", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		m, err := (&GitBackend{URL: dir, Ref: test.ref}).Fetch(context.Background())
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal, got %v", test.name, err)
+		if !test.expectedErr {
+			assert.Equal(t, test.expectedCommit, m.Origin.Commit, "%s: commits should match", test.name)
+			assert.Contains(t, m.Shims, test.expectedShim, "%s: manifest should be read at the ref", test.name)
+		}
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedURL string
+		expectedRef string
+	}{
+		{input: "github.com/org/shims", expectedURL: "github.com/org/shims"},
+		{input: "github.com/org/shims#v2", expectedURL: "github.com/org/shims", expectedRef: "v2"},
+		{input: "git@github.com:org/shims#main", expectedURL: "git@github.com:org/shims", expectedRef: "main"},
+	}
+
+	for _, test := range tests {
+		url, ref := ParseURL(test.input)
+
+		assert.Equal(t, test.expectedURL, url, "%s: URLs should match", test.input)
+		assert.Equal(t, test.expectedRef, ref, "%s: refs should match", test.input)
+	}
+}
+
+// writeTestManifest will write a manifest with a single shim to the path and return it.
+func writeTestManifest(t *testing.T, path string) *manifest.Manifest {
+	m := manifest.CreateManifest(testSourceName)
+	m.Shims["jq"] = shim.Shim{Version: "1", Command: "docker run jq"}
+
+	file, err := os.Create(path)
+	assert.NoError(t, err, "should be no error creating the manifest")
+	assert.NoError(t, m.WriteManifest(file), "should be no error writing the manifest")
+	assert.NoError(t, file.Close(), "should be no error closing the manifest")
+
+	return m
+}
+
+// commitTestRepo will initialize a git repository in the directory if needed and commit everything in it,
+// returning the commit hash.
+func commitTestRepo(t *testing.T, dir string) string {
+	repo, err := git.PlainOpen(dir)
+	if err == git.ErrRepositoryNotExists {
+		repo, err = git.PlainInit(dir, false)
+	}
+
+	assert.NoError(t, err, "should be no error opening the repository")
+
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err, "should be no error getting the worktree")
+	assert.NoError(t, worktree.AddGlob("."), "should be no error adding files")
+
+	hash, err := worktree.Commit("update registry", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err, "should be no error committing")
+
+	return hash.String()
+}
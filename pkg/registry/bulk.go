@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FetchRequest is a registry to fetch as part of a bulk fetch.
+type FetchRequest struct {
+	// Name is how the registry is addressed, which identifies its result.
+	Name string
+
+	// URL is the URL of the registry.
+	URL string
+
+	// Options are how the registry is fetched.
+	Options Options
+}
+
+// FetchResult is the outcome of fetching one registry of a bulk fetch.
+type FetchResult struct {
+	// Request is the fetched registry.
+	Request FetchRequest
+
+	// Registry is the fetched registry, if fetching it succeeded.
+	Registry *Registry
+
+	// Err is why fetching the registry failed.
+	Err error
+
+	// Duration is how long fetching the registry took.
+	Duration time.Duration
+}
+
+// FetchAll will fetch the registries concurrently, with at most parallelism fetches at a time, and return
+// their results in the order they were requested. A failed fetch doesn't stop the others. Progress is
+// called with the result of each fetch as it completes, one at a time. Once the context is done,
+// registries that haven't started fetching fail with the context's error without being fetched.
+func FetchAll(ctx context.Context, requests []FetchRequest, parallelism int, progress func(FetchResult)) []FetchResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]FetchResult, len(requests))
+	indexes := make(chan int)
+	completed := make(chan int)
+
+	workers := sync.WaitGroup{}
+	for worker := 0; worker < parallelism && worker < len(requests); worker++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for idx := range indexes {
+				results[idx] = fetchOne(ctx, requests[idx])
+				completed <- idx
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indexes)
+
+		for idx := range requests {
+			select {
+			case indexes <- idx:
+			case <-ctx.Done():
+				for ; idx < len(requests); idx++ {
+					results[idx] = FetchResult{Request: requests[idx], Err: ctx.Err()}
+				}
+
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(completed)
+	}()
+
+	for idx := range completed {
+		if progress != nil {
+			progress(results[idx])
+		}
+	}
+
+	return results
+}
+
+// fetchOne will fetch one registry of a bulk fetch.
+func fetchOne(ctx context.Context, request FetchRequest) FetchResult {
+	if err := ctx.Err(); err != nil {
+		return FetchResult{Request: request, Err: err}
+	}
+
+	start := time.Now()
+	r, err := GetRegistryWithContext(ctx, request.URL, request.Options)
+
+	return FetchResult{Request: request, Registry: r, Err: err, Duration: time.Since(start)}
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchAll(t *testing.T) {
+	dir := t.TempDir()
+
+	requests := []FetchRequest{}
+	for _, name := range []string{"one", "two", "three", "missing"} {
+		registryDir := filepath.Join(dir, name)
+		assert.NoError(t, os.Mkdir(registryDir, 0700), "should be no error creating the registry")
+
+		if name != "missing" {
+			writeTestManifest(t, filepath.Join(registryDir, ManifestFilename))
+		}
+
+		requests = append(requests, FetchRequest{Name: name, URL: registryDir})
+	}
+
+	progressed := map[string]bool{}
+	results := FetchAll(context.Background(), requests, 2, func(result FetchResult) {
+		progressed[result.Request.Name] = true
+	})
+
+	assert.Len(t, results, len(requests), "every registry should have a result")
+	for idx, result := range results {
+		assert.Equal(t, requests[idx], result.Request, "results should be in request order")
+		assert.True(t, progressed[result.Request.Name], "%s: progress should be reported", result.Request.Name)
+
+		if result.Request.Name == "missing" {
+			assert.Error(t, result.Err, "fetching a missing manifest should error")
+		} else {
+			assert.NoError(t, result.Err, "%s: fetching should not error", result.Request.Name)
+			assert.Equal(t, testSourceName, result.Registry.GetManifest().Source, "%s: the manifest should be read", result.Request.Name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results = FetchAll(ctx, requests, 1, nil)
+	assert.Len(t, results, len(requests), "every registry should have a result")
+	for _, result := range results {
+		assert.Error(t, result.Err, "%s: fetching with a canceled context should error", result.Request.Name)
+	}
+}
@@ -0,0 +1,281 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/gofrs/flock"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// cacheLockSuffix is the suffix of the lock file guarding each cached clone.
+	cacheLockSuffix = ".lock"
+
+	// cacheTmpSuffix is the suffix of the temporary directories fresh clones are made in.
+	cacheTmpSuffix = ".tmp"
+
+	// cacheLockTimeout is how long to wait for another conshim to finish with a cached clone before
+	// falling back to a temporary clone.
+	cacheLockTimeout = 30 * time.Second
+
+	// cacheLockRetryDelay is how often the lock of a cached clone is retried.
+	cacheLockRetryDelay = 100 * time.Millisecond
+)
+
+var (
+	// errCacheUnavailable means the cached clone can't be used at all, so a temporary clone is needed.
+	errCacheUnavailable = errors.New("registry cache is unavailable")
+
+	// CacheDirectory is the directory git registries keep their clones in, so that updates only fetch what
+	// changed. If it's empty, git registries are cloned into a temporary directory every time.
+	CacheDirectory string
+
+	// fetchRefSpecs are the refs kept up to date in cached clones. Branches are kept as remote branches,
+	// like a clone does, and tags are forced so that moved tags are picked up.
+	fetchRefSpecs = []config.RefSpec{
+		"+refs/heads/*:refs/remotes/origin/*",
+		"+refs/tags/*:refs/tags/*",
+	}
+)
+
+// readRepository reads the manifest from a clone of a registry.
+type readRepository func(repo *git.Repository) (*manifest.Manifest, error)
+
+// CacheKey will return the name of the cached clone of the git registry at the URL.
+func CacheKey(url string) string {
+	return manifest.SourceHash(mungeURL(url))
+}
+
+// PruneCache will remove the cached clones that don't belong to any of the registry URLs, or all of them
+// if no URLs are given, returning the paths of the removed clones. Clones in use by another conshim are
+// left alone.
+func PruneCache(keepURLs []string) ([]string, error) {
+	removed := []string{}
+
+	if CacheDirectory == "" {
+		return removed, nil
+	}
+
+	keep := map[string]bool{}
+	for _, url := range keepURLs {
+		keep[CacheKey(url)] = true
+	}
+
+	entries, err := ioutil.ReadDir(CacheDirectory)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return removed, nil
+		}
+
+		return nil, errors.Wrap(err, "error listing registry cache")
+	}
+
+	for _, entry := range entries {
+		// Temporary clones left behind by interrupted fetches are removed too, under their clone's lock.
+		key := strings.SplitN(entry.Name(), cacheTmpSuffix, 2)[0]
+
+		if !entry.IsDir() {
+			removeStaleLock(entry.Name(), keep)
+			continue
+		}
+
+		if keep[key] && key == entry.Name() {
+			continue
+		}
+
+		cachePath := filepath.Join(CacheDirectory, entry.Name())
+		lock := flock.New(filepath.Join(CacheDirectory, key+cacheLockSuffix))
+
+		locked, lockErr := lock.TryLock()
+		if lockErr != nil || !locked {
+			zap.S().Warnf("skipping cached clone '%s' because it's in use", cachePath)
+			continue
+		}
+
+		removeErr := os.RemoveAll(cachePath)
+
+		if unlockErr := lock.Unlock(); unlockErr != nil {
+			zap.S().Warnf("error unlocking cached clone lock: %v", unlockErr)
+		}
+
+		if removeErr != nil {
+			return removed, errors.Wrapf(removeErr, "error removing cached clone '%s'", cachePath)
+		}
+
+		if !keep[key] {
+			if removeErr = os.Remove(lock.Path()); removeErr != nil && !os.IsNotExist(removeErr) {
+				zap.S().Warnf("error removing cached clone lock: %v", removeErr)
+			}
+		}
+
+		removed = append(removed, cachePath)
+	}
+
+	return removed, nil
+}
+
+// removeStaleLock will remove the lock file if it belongs to a clone that isn't kept and isn't in use,
+// such as the lock of a registry that failed to clone.
+func removeStaleLock(name string, keep map[string]bool) {
+	key := strings.TrimSuffix(name, cacheLockSuffix)
+	if key == name || keep[key] {
+		return
+	}
+
+	lock := flock.New(filepath.Join(CacheDirectory, name))
+	if locked, err := lock.TryLock(); err != nil || !locked {
+		return
+	}
+
+	if err := os.Remove(lock.Path()); err != nil {
+		zap.S().Warnf("error removing cached clone lock: %v", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		zap.S().Warnf("error unlocking cached clone lock: %v", err)
+	}
+}
+
+// readCachedClone will bring the cached clone of the repository up to date with an incremental fetch,
+// cloning it if it isn't cached yet, and read the manifest from it with read. A cached clone that can't be
+// opened or fetched, or whose objects can't be read, is replaced with a fresh clone. Other errors from
+// read, such as a missing ref or an invalid manifest, are returned as they are.
+func readCachedClone(ctx context.Context, url string, auth transport.AuthMethod, read readRepository) (*manifest.Manifest, error) {
+	cachePath := filepath.Join(CacheDirectory, CacheKey(url))
+
+	if err := os.MkdirAll(CacheDirectory, 0700); err != nil {
+		return nil, errors.Wrap(errCacheUnavailable, err.Error())
+	}
+
+	lock := flock.New(cachePath + cacheLockSuffix)
+
+	lockCtx, cancel := context.WithTimeout(ctx, cacheLockTimeout)
+	defer cancel()
+
+	if locked, err := lock.TryLockContext(lockCtx, cacheLockRetryDelay); err != nil || !locked {
+		return nil, errors.Wrap(errCacheUnavailable, "cached clone of registry is in use by another conshim")
+	}
+
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			zap.S().Warnf("error unlocking cached clone lock: %v", err)
+		}
+	}()
+
+	repo, fetchErr := fetchCachedClone(ctx, cachePath, url, auth)
+
+	if fetchErr == nil {
+		m, err := read(repo)
+
+		// A fresh clone would be missing the same ref or have the same invalid manifest, so only a clone
+		// whose objects can't be read is replaced.
+		if !isObjectError(err) {
+			return m, err
+		}
+
+		fetchErr = err
+	}
+
+	_, statErr := os.Stat(cachePath)
+	cached := statErr == nil
+
+	repo, err := replaceCachedClone(ctx, cachePath, url, auth)
+
+	if err != nil {
+		// The fetch error says more about why a cached registry couldn't be updated, such as a lost
+		// connection, than the clone that was tried after it.
+		if cached {
+			return nil, fetchErr
+		}
+
+		return nil, err
+	}
+
+	if cached {
+		zap.S().Warnf("replaced cached clone of registry '%s' with a fresh clone: %v", url, fetchErr)
+	}
+
+	return read(repo)
+}
+
+// fetchCachedClone will open the cached clone and fetch what changed in the repository since it was last
+// fetched.
+func fetchCachedClone(ctx context.Context, cachePath, url string, auth transport.AuthMethod) (*git.Repository, error) {
+	repo, err := git.PlainOpen(cachePath)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening cached clone")
+	}
+
+	remote, err := repo.Remote(git.DefaultRemoteName)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading remote of cached clone")
+	}
+
+	if urls := remote.Config().URLs; len(urls) != 1 || urls[0] != url {
+		return nil, errors.New("cached clone is of a different repository")
+	}
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: fetchRefSpecs,
+		Auth:     auth,
+		Force:    true,
+	})
+
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return nil, errors.Wrap(err, "error fetching registry into cached clone")
+	}
+
+	// Fetching only downloads what the clone is missing, so objects it already has aren't checked until
+	// the manifest is read.
+	return repo, nil
+}
+
+// replaceCachedClone will clone the repository next to the cached clone and swap it in, so that a failed
+// clone, such as one without network access, doesn't lose the existing cached clone.
+func replaceCachedClone(ctx context.Context, cachePath, url string, auth transport.AuthMethod) (*git.Repository, error) {
+	tmpDir, err := ioutil.TempDir(filepath.Dir(cachePath), filepath.Base(cachePath)+cacheTmpSuffix)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating temporary directory for cached clone")
+	}
+
+	defer func() {
+		if removeErr := os.RemoveAll(tmpDir); removeErr != nil {
+			zap.S().Errorf("error removing temporary directory during registry retrieval: %v", removeErr)
+		}
+	}()
+
+	// Only the manifest is read, from the commit's tree, so there's no need for a worktree.
+	if _, err = git.PlainCloneContext(ctx, tmpDir, true, &git.CloneOptions{URL: url, Auth: auth}); err != nil {
+		return nil, errors.Wrap(err, "error cloning git repository during registry retrieval")
+	}
+
+	if err = os.RemoveAll(cachePath); err != nil {
+		return nil, errors.Wrap(err, "error removing cached clone")
+	}
+
+	if err = os.Rename(tmpDir, cachePath); err != nil {
+		return nil, errors.Wrap(err, "error moving clone into the registry cache")
+	}
+
+	repo, err := git.PlainOpen(cachePath)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening cached clone")
+	}
+
+	return repo, nil
+}
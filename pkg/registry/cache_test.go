@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitBackendCache(t *testing.T) {
+	defer setTestCacheDirectory(t)()
+
+	dir := t.TempDir()
+	writeTestManifest(t, filepath.Join(dir, ManifestFilename))
+	firstCommit := commitTestRepo(t, dir)
+
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(t, err, "should be no error opening the repository")
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/stable", plumbing.NewHash(firstCommit))), "should be no error branching")
+
+	m, err := (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching should not error")
+	assert.Equal(t, firstCommit, m.Origin.Commit, "the first commit should be read")
+
+	cachePath := filepath.Join(CacheDirectory, CacheKey(dir))
+	assert.DirExists(t, cachePath, "the clone should be cached")
+
+	updated := manifest.CreateManifest(testSourceName)
+	updated.Shims["yq"] = shim.Shim{Version: "2", Command: "docker run yq"}
+	file, err := os.Create(filepath.Join(dir, ManifestFilename))
+	assert.NoError(t, err, "should be no error creating the manifest")
+	assert.NoError(t, updated.WriteManifest(file), "should be no error writing the manifest")
+	assert.NoError(t, file.Close(), "should be no error closing the manifest")
+	secondCommit := commitTestRepo(t, dir)
+
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/stable", plumbing.NewHash(secondCommit))), "should be no error moving the branch")
+
+	m, err = (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching into the cached clone should not error")
+	assert.Equal(t, secondCommit, m.Origin.Commit, "the default branch should be fetched")
+	assert.Contains(t, m.Shims, "yq", "the updated manifest should be read")
+
+	m, err = (&GitBackend{URL: dir, Ref: "stable"}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching a branch into the cached clone should not error")
+	assert.Equal(t, secondCommit, m.Origin.Commit, "moved branches should be fetched")
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cachePath, "config"), []byte("[corrupt"), 0600), "should be no error corrupting the cache")
+
+	m, err = (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching with a corrupt cached clone should not error")
+	assert.Equal(t, secondCommit, m.Origin.Commit, "a corrupt cached clone should be replaced")
+
+	_, err = (&GitBackend{URL: filepath.Join(dir, "missing")}).Fetch(context.Background())
+	assert.Error(t, err, "fetching a missing repository should error")
+	assert.NoDirExists(t, filepath.Join(CacheDirectory, CacheKey(filepath.Join(dir, "missing"))), "failed clones should not be cached")
+}
+
+func TestGitBackendCacheCorruptObjects(t *testing.T) {
+	defer setTestCacheDirectory(t)()
+
+	dir := t.TempDir()
+	writeTestManifest(t, filepath.Join(dir, ManifestFilename))
+	commit := commitTestRepo(t, dir)
+
+	_, err := (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching should not error")
+
+	packs, err := filepath.Glob(filepath.Join(CacheDirectory, CacheKey(dir), "objects", "pack", "*.pack"))
+	assert.NoError(t, err, "should be no error listing the packs")
+	assert.NotEmpty(t, packs, "the cached clone should have packs")
+
+	// Keep the pack indexes so the objects are found, but make them unreadable. Nothing changed upstream,
+	// so fetching into the clone won't notice.
+	for _, pack := range packs {
+		info, statErr := os.Stat(pack)
+		assert.NoError(t, statErr, "should be no error reading the pack")
+		assert.NoError(t, ioutil.WriteFile(pack, make([]byte, info.Size()), 0600), "should be no error corrupting the pack")
+	}
+
+	m, err := (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching with corrupt objects in the cached clone should not error")
+	assert.Equal(t, commit, m.Origin.Commit, "a cached clone with corrupt objects should be replaced")
+
+	m, err = (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching from the replaced clone should not error")
+	assert.Equal(t, commit, m.Origin.Commit, "the replaced clone should be cached")
+}
+
+func TestGitBackendCacheKeptOnReadErrors(t *testing.T) {
+	defer setTestCacheDirectory(t)()
+
+	dir := t.TempDir()
+	writeTestManifest(t, filepath.Join(dir, ManifestFilename))
+	commitTestRepo(t, dir)
+
+	_, err := (&GitBackend{URL: dir}).Fetch(context.Background())
+	assert.NoError(t, err, "fetching should not error")
+
+	// A replaced clone wouldn't have the marker.
+	marker := filepath.Join(CacheDirectory, CacheKey(dir), "marker")
+	assert.NoError(t, ioutil.WriteFile(marker, nil, 0600), "should be no error marking the cached clone")
+
+	tests := []struct {
+		name    string
+		backend *GitBackend
+	}{
+		{name: "missing ref", backend: &GitBackend{URL: dir, Ref: "missing"}},
+		{name: "missing path", backend: &GitBackend{URL: dir, Path: "missing.json"}},
+		{name: "missing directory", backend: &GitBackend{URL: dir, Path: "missing/manifest.json"}},
+	}
+
+	for _, test := range tests {
+		_, err := test.backend.Fetch(context.Background())
+		assert.Error(t, err, "%s: fetching should error", test.name)
+		assert.False(t, isObjectError(err), "%s: error should not be an object error", test.name)
+		assert.FileExists(t, marker, "%s: the cached clone should not be replaced", test.name)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	defer setTestCacheDirectory(t)()
+
+	keptURL := "github.com/some/kept"
+	prunedURL := "github.com/some/pruned"
+
+	for _, name := range []string{CacheKey(keptURL), CacheKey(prunedURL), CacheKey(keptURL) + cacheTmpSuffix + "123"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(CacheDirectory, name), 0700), "should be no error creating the cached clone")
+	}
+
+	staleLock := filepath.Join(CacheDirectory, CacheKey("github.com/some/failed")+cacheLockSuffix)
+	assert.NoError(t, ioutil.WriteFile(staleLock, nil, 0600), "should be no error creating the lock")
+
+	removed, err := PruneCache([]string{"https://" + keptURL})
+	assert.NoError(t, err, "pruning should not error")
+	assert.ElementsMatch(t, []string{
+		filepath.Join(CacheDirectory, CacheKey(prunedURL)),
+		filepath.Join(CacheDirectory, CacheKey(keptURL)+cacheTmpSuffix+"123"),
+	}, removed, "unused clones and temporary clones should be pruned")
+	assert.DirExists(t, filepath.Join(CacheDirectory, CacheKey(keptURL)), "clones of registries should be kept")
+	assert.NoFileExists(t, staleLock, "stale locks should be pruned")
+
+	removed, err = PruneCache(nil)
+	assert.NoError(t, err, "pruning should not error")
+	assert.Equal(t, []string{filepath.Join(CacheDirectory, CacheKey(keptURL))}, removed, "all clones should be pruned")
+}
+
+// setTestCacheDirectory will point the cache directory at a temporary directory, returning a function
+// that restores it.
+func setTestCacheDirectory(t *testing.T) func() {
+	previous := CacheDirectory
+	CacheDirectory = t.TempDir()
+
+	return func() {
+		CacheDirectory = previous
+	}
+}
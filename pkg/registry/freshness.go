@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StaleAction is what commands do when they read a registry that was fetched longer ago than the TTL.
+type StaleAction string
+
+const (
+	// StaleActionWarn warns that the registry is stale and uses it anyway.
+	StaleActionWarn StaleAction = "warn"
+
+	// StaleActionRefresh fetches the registry again before using it, falling back to the stale copy if
+	// the fetch fails.
+	StaleActionRefresh StaleAction = "refresh"
+)
+
+var (
+	// TTL is how long after being fetched a registry becomes stale. If it's zero, registries never become
+	// stale.
+	TTL time.Duration
+
+	// Offline forbids fetching registries and pulling images over the network, so that only local
+	// registries, cached copies and images that were already pulled are used.
+	Offline bool
+
+	// ErrOffline is returned when a registry would need to be fetched over the network in offline mode.
+	ErrOffline = errors.New("network access is disabled in offline mode")
+)
+
+// ParseStaleAction will parse the name of a stale action.
+func ParseStaleAction(name string) (StaleAction, error) {
+	switch StaleAction(name) {
+	case StaleActionWarn, StaleActionRefresh:
+		return StaleAction(name), nil
+	default:
+		return "", fmt.Errorf("unknown stale registry action '%s', expected one of %s, %s", name, StaleActionWarn, StaleActionRefresh)
+	}
+}
+
+// IsStale will return true if a registry fetched at the time is stale at now. Registries without a known
+// fetch time are stale once there is a TTL.
+func IsStale(fetchedAt, now time.Time) bool {
+	if TTL <= 0 {
+		return false
+	}
+
+	return fetchedAt.IsZero() || now.Sub(fetchedAt) > TTL
+}
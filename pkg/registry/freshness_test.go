@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsStale(t *testing.T) {
+	previous := TTL
+	defer func() { TTL = previous }()
+
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		fetchedAt time.Time
+		expected  bool
+	}{
+		{name: "no TTL", ttl: 0, fetchedAt: now.Add(-365 * 24 * time.Hour), expected: false},
+		{name: "fresh", ttl: time.Hour, fetchedAt: now.Add(-time.Minute), expected: false},
+		{name: "stale", ttl: time.Hour, fetchedAt: now.Add(-2 * time.Hour), expected: true},
+		{name: "unknown fetch time", ttl: time.Hour, expected: true},
+	}
+
+	for _, test := range tests {
+		TTL = test.ttl
+		assert.Equal(t, test.expected, IsStale(test.fetchedAt, now), "%s: staleness should match", test.name)
+	}
+}
+
+func TestParseStaleAction(t *testing.T) {
+	action, err := ParseStaleAction("refresh")
+	assert.NoError(t, err, "parsing a known action should not error")
+	assert.Equal(t, StaleActionRefresh, action, "actions should match")
+
+	_, err = ParseStaleAction("ignore")
+	assert.Error(t, err, "parsing an unknown action should error")
+}
+
+func TestOffline(t *testing.T) {
+	Offline = true
+	defer func() { Offline = false }()
+
+	dir := t.TempDir()
+
+	_, err := NewBackend(dir, Options{})
+	assert.NoError(t, err, "local registries should be readable offline")
+
+	for _, url := range []string{"github.com/some/repo", "https://artifacts.example.com/shims/manifest.br"} {
+		_, err = NewBackend(url, Options{})
+		assert.ErrorIs(t, err, ErrOffline, "%s: remote registries should not be fetched offline", url)
+	}
+}
@@ -0,0 +1,231 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// GitBackend reads a registry from a git repository.
+type GitBackend struct {
+	// URL is the URL of the git repository.
+	URL string
+
+	// Ref is the branch, tag or commit to read the manifest from. If it's empty, the default branch is used.
+	Ref string
+
+	// Path is the path of the manifest file within the repository.
+	Path string
+
+	// Auth is how the repository authenticates.
+	Auth manifest.Auth
+}
+
+// Fetch will clone the repository and read the manifest at the path from it at the ref. The resolved
+// commit is recorded in the manifest's origin. If there's a cache directory, the clone is kept there and
+// later fetches only download what changed.
+func (g *GitBackend) Fetch(ctx context.Context) (*manifest.Manifest, error) {
+	auth, err := authMethod(ctx, g.URL, g.Auth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g.readClone(ctx, auth, func(repo *git.Repository) (*manifest.Manifest, error) {
+		commit, err := resolveRef(repo, g.Ref)
+
+		if err != nil {
+			return nil, err
+		}
+
+		manifestPath := g.Path
+		if manifestPath == "" {
+			manifestPath = ManifestFilename
+		}
+
+		m, err := readManifestAtCommit(repo, commit, manifestPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.Origin = &manifest.Origin{Commit: commit.String()}
+
+		return m, nil
+	})
+}
+
+// readClone will read the manifest from an up to date clone of the repository with read, from the cache
+// if there is one.
+func (g *GitBackend) readClone(ctx context.Context, auth transport.AuthMethod, read readRepository) (*manifest.Manifest, error) {
+	if CacheDirectory != "" {
+		m, err := readCachedClone(ctx, g.URL, auth, read)
+
+		if !errors.Is(err, errCacheUnavailable) {
+			return m, err
+		}
+
+		zap.S().Warnf("falling back to a temporary clone of registry '%s': %v", g.URL, err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating temporary directory while retrieving registry")
+	}
+
+	defer func() {
+		if removeErr := os.RemoveAll(tmpDir); removeErr != nil {
+			zap.S().Errorf("error removing temporary directory during registry retrieval: %v", removeErr)
+		}
+	}()
+
+	// Only the manifest is read, from the commit's tree, so there's no need for a worktree.
+	repo, err := git.PlainCloneContext(ctx, tmpDir, true, &git.CloneOptions{
+		URL:  g.URL,
+		Auth: auth,
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error cloning git repository during registry retrieval")
+	}
+
+	return read(repo)
+}
+
+// resolveRef will resolve a branch, tag or commit to a commit hash. Remote branches are preferred over
+// local ones, since local branches aren't updated by fetches. An empty ref resolves to the default branch
+// the repository was cloned with.
+func resolveRef(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	if ref == "" {
+		head, err := repo.Reference(plumbing.HEAD, false)
+
+		if err != nil {
+			return plumbing.ZeroHash, errors.Wrap(err, "error resolving the default branch of the registry")
+		}
+
+		if head.Type() != plumbing.SymbolicReference {
+			return head.Hash(), nil
+		}
+
+		ref = head.Target().Short()
+	}
+
+	for _, revision := range []string{"refs/remotes/origin/" + ref, ref} {
+		if hash, err := repo.ResolveRevision(plumbing.Revision(revision)); err == nil {
+			return *hash, nil
+		}
+	}
+
+	// Resolving a revision reports a commit that can't be read as a missing ref, so a ref that exists but
+	// didn't resolve means the clone's objects are broken.
+	for _, name := range []string{"refs/remotes/origin/" + ref, "refs/tags/" + ref, "refs/heads/" + ref} {
+		if _, err := repo.Reference(plumbing.ReferenceName(name), true); err == nil {
+			return plumbing.ZeroHash, objectError{fmt.Errorf("error reading the commit of ref '%s' in the registry", ref)}
+		}
+	}
+
+	return plumbing.ZeroHash, fmt.Errorf("ref '%s' is not a branch, tag or commit in the registry", ref)
+}
+
+// readManifestAtCommit will read the manifest at the path from the tree of the commit. Errors reading the
+// clone's objects are returned as objectErrors, unlike a missing or invalid manifest.
+func readManifestAtCommit(repo *git.Repository, hash plumbing.Hash, path string) (*manifest.Manifest, error) {
+	commit, err := repo.CommitObject(hash)
+
+	if err != nil {
+		return nil, objectError{errors.Wrapf(err, "error reading commit %s of the registry", hash)}
+	}
+
+	tree, err := commit.Tree()
+
+	if err != nil {
+		return nil, objectError{errors.Wrapf(err, "error reading the tree of commit %s of the registry", hash)}
+	}
+
+	entry, err := tree.FindEntry(path)
+
+	if err == object.ErrDirectoryNotFound || err == object.ErrEntryNotFound {
+		return nil, fmt.Errorf("manifest '%s' does not exist in commit %s of the registry", path, hash)
+	}
+
+	if err != nil {
+		return nil, objectError{errors.Wrapf(err, "error reading the tree of commit %s of the registry", hash)}
+	}
+
+	file, err := tree.TreeEntryFile(entry)
+
+	if err != nil {
+		return nil, objectError{errors.Wrapf(err, "error opening manifest '%s' from git repository during registry retrieval", path)}
+	}
+
+	contents, err := file.Reader()
+
+	if err != nil {
+		return nil, objectError{errors.Wrapf(err, "error opening manifest '%s' from git repository during registry retrieval", path)}
+	}
+
+	defer func() {
+		if closeErr := contents.Close(); closeErr != nil {
+			zap.S().Errorf("error closing manifest file: %v", closeErr)
+		}
+	}()
+
+	reader := &objectReader{reader: contents}
+	m, err := manifest.ReadManifest(reader)
+
+	if reader.err != nil {
+		return nil, objectError{errors.Wrapf(reader.err, "error reading manifest '%s' from git repository during registry retrieval", path)}
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading manifest from git repository during registry retrieval")
+	}
+
+	return m, nil
+}
+
+// objectError is an error reading the objects of a clone, as opposed to a ref or manifest that is missing
+// or invalid, which means the clone itself is broken.
+type objectError struct {
+	error
+}
+
+// Unwrap will return the error reading the objects.
+func (e objectError) Unwrap() error {
+	return e.error
+}
+
+// isObjectError will return whether the error came from reading the objects of a clone.
+func isObjectError(err error) bool {
+	var objErr objectError
+	return errors.As(err, &objErr)
+}
+
+// objectReader reads the contents of an object, remembering the first error reading it so that it can be
+// told apart from an error parsing the contents.
+type objectReader struct {
+	reader io.Reader
+	err    error
+}
+
+// Read will read from the object.
+func (r *objectReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+
+	return n, err
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// httpTimeout is how long downloading a manifest may take.
+	httpTimeout = 60 * time.Second
+)
+
+// HTTPBackend downloads a registry manifest directly from an HTTP(S) URL.
+type HTTPBackend struct {
+	// URL is the URL of the manifest file.
+	URL string
+
+	// Client is the HTTP client used to download the manifest. If it's nil, a client with a timeout is used.
+	Client *http.Client
+
+	// Auth is how the manifest URL authenticates.
+	Auth manifest.Auth
+}
+
+// Fetch will download the manifest.
+func (h *HTTPBackend) Fetch(ctx context.Context) (*manifest.Manifest, error) {
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating manifest request")
+	}
+
+	if !h.Auth.IsZero() {
+		credentials, authErr := authMethod(ctx, h.URL, h.Auth)
+
+		if authErr != nil {
+			return nil, authErr
+		}
+
+		if basicAuth, ok := credentials.(*githttp.BasicAuth); ok {
+			request.SetBasicAuth(basicAuth.Username, basicAuth.Password)
+		}
+	}
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error downloading manifest")
+	}
+
+	defer func() {
+		if closeErr := response.Body.Close(); closeErr != nil {
+			zap.S().Errorf("error closing manifest response: %v", closeErr)
+		}
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading manifest from '%s': %s", h.URL, response.Status)
+	}
+
+	m, err := manifest.ReadManifest(response.Body)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading downloaded manifest")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	fileScheme = "file://"
+)
+
+// LocalBackend reads a registry from the local filesystem.
+type LocalBackend struct {
+	// Path is either the manifest file itself or a directory containing it.
+	Path string
+}
+
+// Fetch will read the manifest from the local filesystem.
+func (l *LocalBackend) Fetch(ctx context.Context) (*manifest.Manifest, error) {
+	manifestPath := l.Path
+
+	if info, err := os.Stat(manifestPath); err == nil && info.IsDir() {
+		manifestPath = filepath.Join(manifestPath, ManifestFilename)
+	}
+
+	manifestFile, err := os.Open(manifestPath)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening manifest from local registry")
+	}
+
+	defer func() {
+		if closeErr := manifestFile.Close(); closeErr != nil {
+			zap.S().Errorf("error closing manifest file: %v", closeErr)
+		}
+	}()
+
+	m, err := manifest.ReadManifest(manifestFile)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading manifest from local registry")
+	}
+
+	return m, nil
+}
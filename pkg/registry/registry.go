@@ -1,14 +1,14 @@
 package registry
 
 import (
-	"io/ioutil"
-	"os"
+	"context"
+	"path"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
 
-	"github.com/go-git/go-git/v5"
 	"github.com/meowfaceman/conshim/pkg/manifest"
-	"github.com/pkg/errors"
-	"go.uber.org/zap"
 )
 
 const (
@@ -21,64 +21,98 @@ var (
 	hasSchema  = regexp.MustCompile(`^[A-Za-z]*://.*$`)
 )
 
-// Registry is a git repository containing a conshim manifest.
+// Registry is a location hosting a conshim manifest.
 type Registry struct {
-	// manifest is the manifest associated with the registry. This manifest is expected to be titled `manifest.br` in the root of the directory.
+	// manifest is the manifest associated with the registry.
 	manifest *manifest.Manifest
 }
 
-// GetRegistry will attempt to get a manifest file from the given registry.
-func GetRegistry(url string) (*Registry, error) {
-	tmpDir, err := ioutil.TempDir("", "")
+// Options are how a registry is fetched.
+type Options struct {
+	// Ref is the branch, tag or commit to pin a git registry to. If it's empty, the default branch is used.
+	Ref string
 
-	if err != nil {
-		return nil, errors.Wrap(err, "error creating temporary directory while retrieving registry")
-	}
+	// Path is the path of the manifest within a git or local registry, which lets one repository host
+	// several registries. It may be the manifest file or the directory containing it. If it's empty, the
+	// manifest at the root is used.
+	Path string
 
-	defer func() {
-		if removeErr := os.RemoveAll(tmpDir); removeErr != nil {
-			zap.S().Errorf("error removing temporary directory during registry retrieval: %v", removeErr)
-		}
-	}()
+	// Auth is how a private git or HTTP(S) registry authenticates.
+	Auth manifest.Auth
+}
 
-	// Clone the repository and try to get the manifest file from the root.
-	repo, err := git.PlainClone(tmpDir, false, &git.CloneOptions{
-		URL: mungeURL(url),
-	})
+// manifestPath will return the path of the manifest file within the registry.
+func (o Options) manifestPath() string {
+	if o.Path == "" {
+		return ManifestFilename
+	}
 
-	if err != nil {
-		return nil, errors.Wrap(err, "error cloning git repository during registry retrieval")
+	cleaned := path.Clean(strings.TrimPrefix(filepath.ToSlash(o.Path), "/"))
+	if path.Ext(cleaned) != path.Ext(ManifestFilename) {
+		cleaned = path.Join(cleaned, ManifestFilename)
 	}
 
-	worktree, err := repo.Worktree()
+	return cleaned
+}
+
+// GetRegistry will attempt to get a manifest file from the given registry, using the backend selected by
+// the registry URL. Git registries can be pinned to a ref with a "#ref" suffix on the URL.
+func GetRegistry(url string) (*Registry, error) {
+	url, ref := ParseURL(url)
+
+	return GetRegistryWithOptions(url, Options{Ref: ref})
+}
+
+// GetRegistryWithOptions will attempt to get a manifest file from the given registry with the options. The
+// fetched manifest records where it was fetched from in its origin.
+func GetRegistryWithOptions(url string, options Options) (*Registry, error) {
+	return GetRegistryWithContext(context.Background(), url, options)
+}
+
+// GetRegistryWithContext will attempt to get a manifest file from the given registry with the options,
+// giving up once the context is done.
+func GetRegistryWithContext(ctx context.Context, url string, options Options) (*Registry, error) {
+	backend, err := NewBackend(url, options)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "error getting worktree during registry retrieval")
+		return nil, err
 	}
 
-	manifestFile, err := worktree.Filesystem.Open(ManifestFilename)
+	m, err := backend.Fetch(ctx)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "error opening manifest from git repository during registry retrieval")
+		return nil, err
 	}
 
-	defer func() {
-		if closeErr := manifestFile.Close(); closeErr != nil {
-			zap.S().Errorf("error closing manifest file: %v", err)
-		}
-	}()
-
-	manifest, err := manifest.ReadManifest(manifestFile)
+	if m.Origin == nil {
+		m.Origin = &manifest.Origin{}
+	}
 
-	if err != nil {
-		return nil, errors.Wrap(err, "error reading manifest from git repository during registry retrieval")
+	m.Origin.URL = url
+	m.Origin.Ref = options.Ref
+	m.Origin.Path = options.Path
+	m.Origin.Auth = nil
+	if !options.Auth.IsZero() {
+		auth := options.Auth
+		m.Origin.Auth = &auth
 	}
 
+	m.Origin.FetchedAt = time.Now().UTC()
+
 	return &Registry{
-		manifest: manifest,
+		manifest: m,
 	}, nil
 }
 
+// ParseURL will split a registry URL in the form "url#ref" into the URL and the ref.
+func ParseURL(url string) (string, string) {
+	if idx := strings.LastIndex(url, "#"); idx >= 0 {
+		return url[:idx], url[idx+1:]
+	}
+
+	return url, ""
+}
+
 // GetManifest will return the manifest from the registry.
 func (r *Registry) GetManifest() *manifest.Manifest {
 	return r.manifest
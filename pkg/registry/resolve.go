@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+)
+
+// ShimProvider is a registry that an unqualified shim name is resolved against.
+type ShimProvider struct {
+	// Registry is the name of the registry.
+	Registry string
+
+	// Prioritized is whether the registry is in the priority list.
+	Prioritized bool
+
+	// Manifest is the manifest of the registry.
+	Manifest *manifest.Manifest
+}
+
+// ShimNotFoundError is returned when no registry provides a shim.
+type ShimNotFoundError struct {
+	// Shim is the name of the shim.
+	Shim string
+}
+
+// Error will return the error message.
+func (e *ShimNotFoundError) Error() string {
+	return fmt.Sprintf("no registry provides shim '%s'", e.Shim)
+}
+
+// AmbiguousShimError is returned when several registries outside the priority list provide a shim and none
+// in the priority list does, so there is no way to choose between them.
+type AmbiguousShimError struct {
+	// Shim is the name of the shim.
+	Shim string
+
+	// Registries are the names of the registries providing the shim.
+	Registries []string
+}
+
+// Error will return the error message.
+func (e *AmbiguousShimError) Error() string {
+	return fmt.Sprintf("shim '%s' is provided by registries %s, use '<registry>/%s' to choose one or give one of them priority", e.Shim, strings.Join(e.Registries, ", "), e.Shim)
+}
+
+// SplitShimName will split a shim name of the form "registry/shim" into the registry and the shim. The
+// registry is empty for unqualified names. Since registry URLs contain slashes and shim names can't (see
+// shim.ValidateName), the name is split at its last slash.
+func SplitShimName(name string) (string, string) {
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+
+	return "", name
+}
+
+// ResolveShim will choose the registry that provides the shim from the providers, which are in priority
+// order with the prioritized registries first. The first prioritized registry providing the shim wins. If
+// none does, exactly one of the other registries must provide it, otherwise an AmbiguousShimError is
+// returned. The names of the other registries providing the shim are returned alongside the choice.
+func ResolveShim(shimName string, providers []ShimProvider) (ShimProvider, []string, error) {
+	matches := []ShimProvider{}
+	for _, provider := range providers {
+		if _, ok := provider.Manifest.GetShim(shimName); ok {
+			matches = append(matches, provider)
+		}
+	}
+
+	if len(matches) == 0 {
+		return ShimProvider{}, nil, &ShimNotFoundError{Shim: shimName}
+	}
+
+	others := []string{}
+	for _, match := range matches[1:] {
+		others = append(others, match.Registry)
+	}
+
+	if len(matches) > 1 && !matches[0].Prioritized {
+		return ShimProvider{}, nil, &AmbiguousShimError{Shim: shimName, Registries: append([]string{matches[0].Registry}, others...)}
+	}
+
+	return matches[0], others, nil
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitShimName(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedRegistry string
+		expectedShim     string
+	}{
+		{input: "jq", expectedRegistry: "", expectedShim: "jq"},
+		{input: "tools/jq", expectedRegistry: "tools", expectedShim: "jq"},
+		{input: "github.com/org/shims/jq", expectedRegistry: "github.com/org/shims", expectedShim: "jq"},
+	}
+
+	for _, test := range tests {
+		registryName, shimName := SplitShimName(test.input)
+		assert.Equal(t, test.expectedRegistry, registryName, "%s: registries should match", test.input)
+		assert.Equal(t, test.expectedShim, shimName, "%s: shims should match", test.input)
+	}
+}
+
+func TestResolveShim(t *testing.T) {
+	providing := func(name string, prioritized bool, shimNames ...string) ShimProvider {
+		m := manifest.CreateManifest(name)
+		for _, shimName := range shimNames {
+			m.Shims[shimName] = shim.Shim{Version: "1", Command: "docker run " + shimName}
+		}
+
+		return ShimProvider{Registry: name, Prioritized: prioritized, Manifest: m}
+	}
+
+	tests := []struct {
+		name             string
+		providers        []ShimProvider
+		expectedRegistry string
+		expectedOthers   []string
+		expectedErr      interface{}
+	}{
+		{
+			name:             "single provider",
+			providers:        []ShimProvider{providing("one", false, "yq"), providing("two", false, "jq")},
+			expectedRegistry: "two",
+			expectedOthers:   []string{},
+		},
+		{
+			name:             "prioritized provider",
+			providers:        []ShimProvider{providing("one", true, "yq"), providing("two", true, "jq"), providing("three", false, "jq")},
+			expectedRegistry: "two",
+			expectedOthers:   []string{"three"},
+		},
+		{
+			name:             "highest priority",
+			providers:        []ShimProvider{providing("one", true, "jq"), providing("two", true, "jq")},
+			expectedRegistry: "one",
+			expectedOthers:   []string{"two"},
+		},
+		{
+			name:        "ambiguous",
+			providers:   []ShimProvider{providing("one", true, "yq"), providing("two", false, "jq"), providing("three", false, "jq")},
+			expectedErr: &AmbiguousShimError{},
+		},
+		{
+			name:        "not found",
+			providers:   []ShimProvider{providing("one", true, "yq")},
+			expectedErr: &ShimNotFoundError{},
+		},
+	}
+
+	for _, test := range tests {
+		provider, others, err := ResolveShim("jq", test.providers)
+
+		if test.expectedErr != nil {
+			assert.IsType(t, test.expectedErr, err, "%s: errors should match", test.name)
+			continue
+		}
+
+		assert.NoError(t, err, "%s: resolving should not error", test.name)
+		assert.Equal(t, test.expectedRegistry, provider.Registry, "%s: registries should match", test.name)
+		assert.Equal(t, test.expectedOthers, others, "%s: other registries should match", test.name)
+	}
+}
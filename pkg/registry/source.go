@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SourceMismatchError is returned when a registry's manifest declares a source other than the URL it was
+// fetched from. Such a registry could shadow the registry it claims to be.
+type SourceMismatchError struct {
+	// Source is the source the manifest declares.
+	Source string
+
+	// URL is the URL the manifest was fetched from.
+	URL string
+}
+
+// Error will return the error message.
+func (e *SourceMismatchError) Error() string {
+	return fmt.Sprintf("registry '%s' declares its source as '%s', which doesn't match the URL it was fetched from", e.URL, e.Source)
+}
+
+// CheckSource will return a SourceMismatchError if the declared source of a manifest doesn't match the URL
+// of the registry it was fetched from with the options. Registries with a manifest path may also declare
+// the URL followed by the directory of the manifest as their source.
+func CheckSource(source, registryURL string, options Options) error {
+	normalizedSource := NormalizeURL(source)
+	normalizedURL := NormalizeURL(registryURL)
+
+	if normalizedSource == normalizedURL {
+		return nil
+	}
+
+	if options.Path != "" {
+		if dir := path.Dir(options.manifestPath()); dir != "." && normalizedSource == normalizedURL+"/"+dir {
+			return nil
+		}
+	}
+
+	return &SourceMismatchError{Source: source, URL: registryURL}
+}
+
+// NormalizeURL will return a normalized form of a registry URL, such that the different ways of writing the
+// URL of the same registry normalize the same way. URLs are munged like they are when fetching, then their
+// scheme, user and ".git" suffix are dropped and their host is lowercased, so "git@github.com:org/shims",
+// "https://GitHub.com/org/shims.git" and "github.com/org/shims" are the same. Local registries normalize
+// to their absolute path.
+func NormalizeURL(registryURL string) string {
+	if strings.HasPrefix(registryURL, fileScheme) {
+		return normalizePath(strings.TrimPrefix(registryURL, fileScheme))
+	}
+
+	if _, err := os.Stat(registryURL); err == nil {
+		return normalizePath(registryURL)
+	}
+
+	munged := mungeURL(registryURL)
+
+	var host, urlPath string
+	if isSSHRegex.MatchString(munged) {
+		hostAndPath := munged[strings.Index(munged, "@")+1:]
+
+		if idx := strings.Index(hostAndPath, ":"); idx >= 0 {
+			host, urlPath = hostAndPath[:idx], hostAndPath[idx+1:]
+		} else {
+			host = hostAndPath
+		}
+	} else if parsed, err := url.Parse(munged); err == nil && parsed.Host != "" {
+		host, urlPath = parsed.Host, parsed.Path
+	} else {
+		return munged
+	}
+
+	urlPath = strings.TrimSuffix(strings.Trim(urlPath, "/"), ".git")
+	if urlPath == "" {
+		return strings.ToLower(host)
+	}
+
+	return strings.ToLower(host) + "/" + urlPath
+}
+
+// normalizePath will return the absolute, cleaned form of a local registry path.
+func normalizePath(localPath string) string {
+	if absPath, err := filepath.Abs(localPath); err == nil {
+		return filepath.ToSlash(absPath)
+	}
+
+	return filepath.ToSlash(filepath.Clean(localPath))
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+)
+
+const (
+	exactNameScore           = 100
+	namePrefixScore          = 50
+	nameContainsScore        = 25
+	exactTagScore            = 20
+	exactCategoryScore       = 15
+	tagContainsScore         = 10
+	categoryContainsScore    = 8
+	descriptionContainsScore = 5
+)
+
+// Result is a shim that matched a search query.
+type Result struct {
+	// Registry is the source of the manifest the shim was found in.
+	Registry string
+
+	// Shim is the matching shim.
+	Shim shim.Shim
+
+	// Score is the relevance of the shim to the query. Higher is more relevant.
+	Score int
+}
+
+// Search will search the names, descriptions, tags and categories of the shims in the given manifests.
+// Every term in the query must match a shim for it to be returned. Results are ordered by relevance.
+func Search(manifests []*manifest.Manifest, query string) []Result {
+	terms := strings.Fields(strings.ToLower(query))
+
+	results := []Result{}
+	for _, m := range manifests {
+		for shimName := range m.Shims {
+			s, _ := m.GetShim(shimName)
+
+			if score := scoreShim(s, terms); score > 0 {
+				results = append(results, Result{
+					Registry: m.Source,
+					Shim:     s,
+					Score:    score,
+				})
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		if results[i].Shim.Name != results[j].Shim.Name {
+			return results[i].Shim.Name < results[j].Shim.Name
+		}
+
+		return results[i].Registry < results[j].Registry
+	})
+
+	return results
+}
+
+// scoreShim will score the shim against all of the terms. If any term doesn't match, the score is 0.
+func scoreShim(s shim.Shim, terms []string) int {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, term := range terms {
+		score := scoreTerm(s, term)
+
+		if score == 0 {
+			return 0
+		}
+
+		total += score
+	}
+
+	return total
+}
+
+// scoreTerm will return the best score for a single term against the shim.
+func scoreTerm(s shim.Shim, term string) int {
+	name := strings.ToLower(s.Name)
+
+	switch {
+	case name == term:
+		return exactNameScore
+	case strings.HasPrefix(name, term):
+		return namePrefixScore
+	case strings.Contains(name, term):
+		return nameContainsScore
+	}
+
+	best := 0
+	best = maxScore(best, scoreList(s.Tags, term, exactTagScore, tagContainsScore))
+	best = maxScore(best, scoreList(s.Categories, term, exactCategoryScore, categoryContainsScore))
+
+	if strings.Contains(strings.ToLower(s.Description), term) {
+		best = maxScore(best, descriptionContainsScore)
+	}
+
+	return best
+}
+
+// scoreList will score the term against each entry of the list, returning the best score.
+func scoreList(list []string, term string, exactScore, containsScore int) int {
+	best := 0
+	for _, entry := range list {
+		entry = strings.ToLower(entry)
+
+		if entry == term {
+			return exactScore
+		} else if strings.Contains(entry, term) {
+			best = containsScore
+		}
+	}
+
+	return best
+}
+
+// maxScore will return the larger of the two scores.
+func maxScore(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/meowfaceman/conshim/pkg/manifest"
+	"github.com/meowfaceman/conshim/pkg/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+type expectedResult struct {
+	registry string
+	shimName string
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []expectedResult
+	}{
+		{
+			name:  "name matches rank above tags",
+			query: "jq",
+			expected: []expectedResult{
+				{registry: "registry-a", shimName: "jq"},
+				{registry: "registry-b", shimName: "jq"},
+				{registry: "registry-a", shimName: "gojq"},
+				{registry: "registry-b", shimName: "yq"},
+			},
+		},
+		{
+			name:  "category match",
+			query: "cloud",
+			expected: []expectedResult{
+				{registry: "registry-b", shimName: "aws"},
+			},
+		},
+		{
+			name:  "all terms must match",
+			query: "json yaml",
+			expected: []expectedResult{
+				{registry: "registry-b", shimName: "yq"},
+			},
+		},
+		{
+			name:     "no matches",
+			query:    "terraform",
+			expected: []expectedResult{},
+		},
+		{
+			name:     "empty query",
+			query:    "  ",
+			expected: []expectedResult{},
+		},
+	}
+
+	manifests := createTestingManifests(t)
+
+	for _, test := range tests {
+		results := Search(manifests, test.query)
+
+		actual := []expectedResult{}
+		for _, result := range results {
+			actual = append(actual, expectedResult{registry: result.Registry, shimName: result.Shim.Name})
+		}
+
+		assert.Equal(t, test.expected, actual, "%s: results should match", test.name)
+	}
+}
+
+func createTestingManifests(t *testing.T) []*manifest.Manifest {
+	registryA := manifest.CreateManifest("registry-a")
+	assert.NoError(t, registryA.AddShim("jq", shim.Shim{
+		Description: "Command-line JSON processor",
+		Command:     "docker run jq",
+		Tags:        []string{"json"},
+	}), "should not error")
+	assert.NoError(t, registryA.AddShim("gojq", shim.Shim{
+		Description: "Pure Go implementation of jq",
+		Command:     "docker run gojq",
+	}), "should not error")
+
+	registryB := manifest.CreateManifest("registry-b")
+	assert.NoError(t, registryB.AddShim("jq", shim.Shim{
+		Command: "docker run other-jq",
+	}), "should not error")
+	assert.NoError(t, registryB.AddShim("yq", shim.Shim{
+		Description: "YAML processor",
+		Command:     "docker run yq",
+		Tags:        []string{"yaml", "json", "jq"},
+	}), "should not error")
+	assert.NoError(t, registryB.AddShim("aws", shim.Shim{
+		Description: "AWS command line interface",
+		Command:     "docker run aws",
+		Categories:  []string{"cloud"},
+	}), "should not error")
+
+	return []*manifest.Manifest{registryA, registryB}
+}
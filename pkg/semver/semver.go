@@ -0,0 +1,251 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bump is a part of a version that can be incremented.
+type Bump string
+
+const (
+	// BumpMajor increments the major version and resets the minor and patch versions.
+	BumpMajor Bump = "major"
+
+	// BumpMinor increments the minor version and resets the patch version.
+	BumpMinor Bump = "minor"
+
+	// BumpPatch increments the patch version.
+	BumpPatch Bump = "patch"
+
+	// BumpNone leaves the version unchanged.
+	BumpNone Bump = "none"
+)
+
+// Version is a semantic version in the form MAJOR.MINOR.PATCH with an optional pre-release suffix and
+// build metadata.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// Parse will parse a semantic version. A leading "v" is allowed, and missing minor and patch
+// components are treated as 0.
+func Parse(version string) (Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	if trimmed == "" {
+		return Version{}, fmt.Errorf("invalid version '%s': version is empty", version)
+	}
+
+	v := Version{}
+
+	if idx := strings.Index(trimmed, "+"); idx >= 0 {
+		v.Build = trimmed[idx+1:]
+		trimmed = trimmed[:idx]
+	}
+
+	if idx := strings.Index(trimmed, "-"); idx >= 0 {
+		v.Prerelease = trimmed[idx+1:]
+		trimmed = trimmed[:idx]
+	}
+
+	parts := strings.Split(trimmed, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version '%s': too many components", version)
+	}
+
+	components := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		num, err := strconv.Atoi(part)
+
+		if err != nil || num < 0 {
+			return Version{}, fmt.Errorf("invalid version '%s': component '%s' is not a non-negative integer", version, part)
+		}
+
+		*components[i] = num
+	}
+
+	return v, nil
+}
+
+// MustParse will parse a semantic version, panicking if it is invalid.
+func MustParse(version string) Version {
+	v, err := Parse(version)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// String will return the version in the form MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD].
+func (v Version) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.Prerelease != "" {
+		version += "-" + v.Prerelease
+	}
+
+	if v.Build != "" {
+		version += "+" + v.Build
+	}
+
+	return version
+}
+
+// Compare will return -1 if v is lower than other, 1 if v is higher than other, and 0 if they're equal.
+// Pre-release versions are lower than their associated release, and build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] < pair[1] {
+			return -1
+		} else if pair[0] > pair[1] {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.Prerelease, other.Prerelease)
+	}
+}
+
+// comparePrerelease will compare two pre-release suffixes one dot separated identifier at a time. Numeric
+// identifiers are compared by value and are lower than alphanumeric ones, and a suffix with more
+// identifiers is higher if all of the preceding ones are equal.
+func comparePrerelease(a, b string) int {
+	aIdentifiers := strings.Split(a, ".")
+	bIdentifiers := strings.Split(b, ".")
+
+	for i := 0; i < len(aIdentifiers) && i < len(bIdentifiers); i++ {
+		if result := compareIdentifier(aIdentifiers[i], bIdentifiers[i]); result != 0 {
+			return result
+		}
+	}
+
+	return compareInts(len(aIdentifiers), len(bIdentifiers))
+}
+
+// compareIdentifier will compare two pre-release identifiers. Alphanumeric identifiers are compared with
+// their runs of digits compared by value, so that rc2 is lower than rc10.
+func compareIdentifier(a, b string) int {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(aNum, bNum)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	aRuns := splitDigitRuns(a)
+	bRuns := splitDigitRuns(b)
+
+	for i := 0; i < len(aRuns) && i < len(bRuns); i++ {
+		if result := compareRun(aRuns[i], bRuns[i]); result != 0 {
+			return result
+		}
+	}
+
+	return compareInts(len(aRuns), len(bRuns))
+}
+
+// compareRun will compare two runs of an identifier, by value if both are digits.
+func compareRun(a, b string) int {
+	if isDigit(a[0]) && isDigit(b[0]) {
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+
+		if result := compareInts(len(a), len(b)); result != 0 {
+			return result
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+// splitDigitRuns will split the identifier into alternating runs of digits and non-digits.
+func splitDigitRuns(identifier string) []string {
+	runs := []string{}
+
+	start := 0
+	for i := 1; i <= len(identifier); i++ {
+		if i == len(identifier) || isDigit(identifier[i]) != isDigit(identifier[start]) {
+			runs = append(runs, identifier[start:i])
+			start = i
+		}
+	}
+
+	return runs
+}
+
+// isDigit will return true if the byte is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareInts will return -1, 0 or 1 as a is lower than, equal to or higher than b.
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LessThan will return true if v is lower than other.
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Bump will return the version with the given part incremented. Any pre-release suffix is dropped. A
+// pre-release is already lower than its release, so bumping one only drops the suffix when the parts below
+// the bumped one are 0, e.g. a patch bump of 1.2.3-rc1 gives 1.2.3 and a minor bump of 1.3.0-rc1 gives 1.3.0.
+func (v Version) Bump(bump Bump) (Version, error) {
+	release := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	isPrerelease := v.Prerelease != ""
+
+	switch bump {
+	case BumpMajor:
+		if isPrerelease && v.Minor == 0 && v.Patch == 0 {
+			return release, nil
+		}
+
+		return Version{Major: v.Major + 1}, nil
+	case BumpMinor:
+		if isPrerelease && v.Patch == 0 {
+			return release, nil
+		}
+
+		return Version{Major: v.Major, Minor: v.Minor + 1}, nil
+	case BumpPatch:
+		if isPrerelease {
+			return release, nil
+		}
+
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}, nil
+	case BumpNone:
+		return v, nil
+	}
+
+	return Version{}, fmt.Errorf("unknown version bump '%s', expected one of %s, %s, %s or %s",
+		bump, BumpMajor, BumpMinor, BumpPatch, BumpNone)
+}
@@ -0,0 +1,122 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    Version
+		expectedErr bool
+	}{
+		{
+			name:     "full version",
+			input:    "1.2.3",
+			expected: Version{Major: 1, Minor: 2, Patch: 3},
+		},
+		{
+			name:     "leading v",
+			input:    "v1.2.3",
+			expected: Version{Major: 1, Minor: 2, Patch: 3},
+		},
+		{
+			name:     "missing components",
+			input:    "2",
+			expected: Version{Major: 2},
+		},
+		{
+			name:     "prerelease",
+			input:    "1.0.0-rc1",
+			expected: Version{Major: 1, Prerelease: "rc1"},
+		},
+		{
+			name:     "build metadata",
+			input:    "v1.2.0-rc1+3.gabc123.dirty",
+			expected: Version{Major: 1, Minor: 2, Prerelease: "rc1", Build: "3.gabc123.dirty"},
+		},
+		{
+			name:        "empty",
+			input:       "",
+			expectedErr: true,
+		},
+		{
+			name:        "not a number",
+			input:       "1.x.3",
+			expectedErr: true,
+		},
+		{
+			name:        "too many components",
+			input:       "1.2.3.4",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		v, err := Parse(test.input)
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+		if !test.expectedErr {
+			assert.Equal(t, test.expected, v, "%s: versions should match", test.name)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "1.0.0", b: "1.0.0", expected: 0},
+		{a: "1.0.0", b: "1.0.1", expected: -1},
+		{a: "1.1.0", b: "1.0.9", expected: 1},
+		{a: "2.0.0", b: "10.0.0", expected: -1},
+		{a: "1.0.0-rc1", b: "1.0.0", expected: -1},
+		{a: "1.0.0-rc2", b: "1.0.0-rc1", expected: 1},
+		{a: "1.0.0-rc10", b: "1.0.0-rc2", expected: 1},
+		{a: "1.0.0-rc.10", b: "1.0.0-rc.2", expected: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", expected: -1},
+		{a: "1.0.0-1", b: "1.0.0-alpha", expected: -1},
+		{a: "1.0.0-beta", b: "1.0.0-alpha.9", expected: 1},
+		{a: "1.2.0+3.gabc123", b: "1.2.0", expected: 0},
+		{a: "1.2.0+dirty", b: "1.2.0", expected: 0},
+		{a: "1.2.0-rc1+3.gabc123", b: "1.2.0", expected: -1},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, MustParse(test.a).Compare(MustParse(test.b)), "comparing %s to %s", test.a, test.b)
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		input       string
+		bump        Bump
+		expected    string
+		expectedErr bool
+	}{
+		{input: "1.2.3", bump: BumpMajor, expected: "2.0.0"},
+		{input: "1.2.3", bump: BumpMinor, expected: "1.3.0"},
+		{input: "1.2.3", bump: BumpPatch, expected: "1.2.4"},
+		{input: "1.2.3-rc1", bump: BumpPatch, expected: "1.2.3"},
+		{input: "1.2.3-rc1", bump: BumpMinor, expected: "1.3.0"},
+		{input: "1.3.0-rc1", bump: BumpMinor, expected: "1.3.0"},
+		{input: "1.3.0-rc1", bump: BumpMajor, expected: "2.0.0"},
+		{input: "2.0.0-rc1", bump: BumpMajor, expected: "2.0.0"},
+		{input: "1.2.3", bump: BumpNone, expected: "1.2.3"},
+		{input: "1.2.3", bump: "bogus", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		v, err := MustParse(test.input).Bump(test.bump)
+
+		assert.Equal(t, test.expectedErr, err != nil, "bumping %s by %s: error states should equal", test.input, test.bump)
+		if !test.expectedErr {
+			assert.Equal(t, test.expected, v.String(), "bumping %s by %s", test.input, test.bump)
+		}
+	}
+}
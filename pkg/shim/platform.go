@@ -0,0 +1,102 @@
+package shim
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// IsCompatible will return true if the shim is able to run on the given OS and architecture.
+func (s Shim) IsCompatible(goos, goarch string) bool {
+	if _, ok := s.variantFor(goos, goarch); ok {
+		return true
+	}
+
+	if len(s.Platforms) == 0 {
+		return true
+	}
+
+	for _, platform := range s.Platforms {
+		if platformMatches(platform, goos, goarch) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsCompatibleWithHost will return true if the shim is able to run on the current host.
+func (s Shim) IsCompatibleWithHost() bool {
+	return s.IsCompatible(runtime.GOOS, runtime.GOARCH)
+}
+
+// ForPlatform will return the shim as it should be used on the given OS and architecture. If the shim
+// has a variant for the platform, the variant's command will replace the shim's command. If the shim
+// is not compatible with the platform, this will error.
+func (s Shim) ForPlatform(goos, goarch string) (Shim, error) {
+	if command, ok := s.variantFor(goos, goarch); ok {
+		s.Command = command
+		return s, nil
+	}
+
+	if !s.IsCompatible(goos, goarch) {
+		return Shim{}, fmt.Errorf("shim '%s' does not support %s/%s, supported platforms: %s",
+			s.Name, goos, goarch, strings.Join(s.supportedPlatforms(), ","))
+	}
+
+	return s, nil
+}
+
+// ForHost will return the shim as it should be used on the current host.
+func (s Shim) ForHost() (Shim, error) {
+	return s.ForPlatform(runtime.GOOS, runtime.GOARCH)
+}
+
+// variantFor will find the variant command for the given OS and architecture. Variants that specify
+// an architecture take precedence over variants that only specify an OS.
+func (s Shim) variantFor(goos, goarch string) (string, bool) {
+	if command, ok := s.Variants[goos+"/"+goarch]; ok {
+		return command, true
+	}
+
+	for _, platform := range sortedKeys(s.Variants) {
+		if platformMatches(platform, goos, goarch) {
+			return s.Variants[platform], true
+		}
+	}
+
+	return "", false
+}
+
+// supportedPlatforms will return all platforms that the shim declares support for.
+func (s Shim) supportedPlatforms() []string {
+	platforms := append([]string{}, s.Platforms...)
+	platforms = append(platforms, sortedKeys(s.Variants)...)
+
+	return platforms
+}
+
+// platformMatches will return true if the platform string, in the form "os/arch" or "os", matches
+// the given OS and architecture. Any variant suffix, such as "linux/arm/v7", is ignored.
+func platformMatches(platform, goos, goarch string) bool {
+	parts := strings.Split(strings.TrimSpace(platform), "/")
+
+	if parts[0] != goos {
+		return false
+	}
+
+	return len(parts) == 1 || parts[1] == "" || parts[1] == goarch
+}
+
+// sortedKeys will return the keys of the map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,92 @@
+package shim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForPlatform(t *testing.T) {
+	tests := []struct {
+		name            string
+		shim            Shim
+		goos            string
+		goarch          string
+		expectedCommand string
+		expectedErr     bool
+	}{
+		{
+			name:            "no platform constraints",
+			shim:            Shim{Command: "default"},
+			goos:            "linux",
+			goarch:          "arm64",
+			expectedCommand: "default",
+		},
+		{
+			name:            "matching os/arch platform",
+			shim:            Shim{Command: "default", Platforms: []string{"linux/amd64", "darwin/amd64"}},
+			goos:            "darwin",
+			goarch:          "amd64",
+			expectedCommand: "default",
+		},
+		{
+			name:            "matching os-only platform",
+			shim:            Shim{Command: "default", Platforms: []string{"linux"}},
+			goos:            "linux",
+			goarch:          "arm64",
+			expectedCommand: "default",
+		},
+		{
+			name:        "incompatible platform",
+			shim:        Shim{Command: "default", Platforms: []string{"linux/amd64"}},
+			goos:        "linux",
+			goarch:      "arm64",
+			expectedErr: true,
+		},
+		{
+			name: "variant selected over default",
+			shim: Shim{
+				Command:   "default",
+				Platforms: []string{"linux/amd64"},
+				Variants:  map[string]string{"linux/arm64": "arm-command"},
+			},
+			goos:            "linux",
+			goarch:          "arm64",
+			expectedCommand: "arm-command",
+		},
+		{
+			name: "os/arch variant preferred over os variant",
+			shim: Shim{
+				Command: "default",
+				Variants: map[string]string{
+					"darwin":       "darwin-command",
+					"darwin/arm64": "darwin-arm-command",
+				},
+			},
+			goos:            "darwin",
+			goarch:          "arm64",
+			expectedCommand: "darwin-arm-command",
+		},
+		{
+			name: "variants without platforms fall back to default",
+			shim: Shim{
+				Command:  "default",
+				Variants: map[string]string{"linux/arm64": "arm-command"},
+			},
+			goos:            "windows",
+			goarch:          "amd64",
+			expectedCommand: "default",
+		},
+	}
+
+	for _, test := range tests {
+		platformShim, err := test.shim.ForPlatform(test.goos, test.goarch)
+
+		assert.Equal(t, test.expectedErr, err != nil, "%s: error states should equal", test.name)
+		assert.Equal(t, !test.expectedErr, test.shim.IsCompatible(test.goos, test.goarch), "%s: compatibility should match", test.name)
+
+		if !test.expectedErr {
+			assert.Equal(t, test.expectedCommand, platformShim.Command, "%s: commands should match", test.name)
+		}
+	}
+}
@@ -19,6 +19,10 @@ const (
 	// Only support bash templates for now
 	bashTemplate = "bash"
 
+	// ImagePlaceholder is replaced in shim commands with the shim's image reference when rendering.
+	ImagePlaceholder = "{{image}}"
+
+	readErrorMessage            = "error reading contents"
 	shebangMissingErrorMessage  = "unexpected EOF while skipping shebang line"
 	metadataMissingErrorMessage = "unexpected EOF while reading metadata"
 	commandMissingErrorMessage  = "unexpected EOF while reading command"
@@ -27,6 +31,7 @@ const (
 var (
 	templates          = map[string]*template.Template{}
 	sourceVersionRegex = regexp.MustCompile(`^#\s*source:\s*([^\s]+)\s*version:\s*(.+)\s*$`)
+	parameterRegex     = regexp.MustCompile(`\{\{([A-Za-z_][\w-]*)\}\}`)
 )
 
 func init() {
@@ -54,22 +59,75 @@ func init() {
 // Shim is a descriptor of a shim.
 type Shim struct {
 	// Name is the name of the shim.
-	Name string `json:"name,omitempty"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 
 	// Source is the source of the shim.
-	Source string `json:"source,omitempty"`
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
 
 	// Version is the version of the shim represented in the manifest.
-	Version string `json:"version"`
+	Version string `json:"version" yaml:"version,omitempty"`
 
 	// Description is the description string for the shim.
-	Description string `json:"description,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 
 	// Parameters are parameters that can be used for the shim command.
-	Parameters []string `json:"parameters"`
+	Parameters []string `json:"parameters" yaml:"parameters,omitempty"`
 
 	// Command is the shim comman.
-	Command string `json:"command"`
+	Command string `json:"command" yaml:"command,omitempty"`
+
+	// Platforms is a list of platforms the shim is able to run on, in the form "os/arch" or "os". An
+	// empty list means the shim can run anywhere.
+	Platforms []string `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+
+	// Variants maps platforms to commands that should be used in place of Command on that platform.
+	Variants map[string]string `json:"variants,omitempty" yaml:"variants,omitempty"`
+
+	// Tags are free-form keywords used to find the shim.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Categories are broad groupings that the shim belongs to.
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+
+	// Image is the container image used by the shim. It's substituted for {{image}} in the command.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// Digest is the pinned digest of the image, in the form "sha256:...".
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+
+	// MinConshimVersion is the minimum version of conshim needed to use this shim.
+	MinConshimVersion string `json:"minConshimVersion,omitempty" yaml:"minConshimVersion,omitempty"`
+
+	// Upstream records where the shim was vendored from, if it was copied from another registry.
+	Upstream *Upstream `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+}
+
+// Upstream is the provenance of a shim vendored from another registry.
+type Upstream struct {
+	// Source is the source of the registry the shim was vendored from.
+	Source string `json:"source" yaml:"source"`
+
+	// Name is the name of the shim in the upstream registry.
+	Name string `json:"name" yaml:"name"`
+
+	// Version is the version of the shim in the upstream registry when it was vendored.
+	Version string `json:"version" yaml:"version"`
+
+	// ManifestVersion is the version of the upstream manifest when the shim was vendored.
+	ManifestVersion string `json:"manifestVersion" yaml:"manifestVersion"`
+
+	// Checksum is the checksum of the upstream shim when it was vendored. It's used to tell whether the
+	// shim has since changed upstream or locally.
+	Checksum string `json:"checksum" yaml:"checksum"`
+}
+
+// String will return a string representation of the upstream in the form "source name@version".
+func (u Upstream) String() string {
+	if u.Version == "" {
+		return fmt.Sprintf("%s %s", u.Source, u.Name)
+	}
+
+	return fmt.Sprintf("%s %s@%s", u.Source, u.Name, u.Version)
 }
 
 // String will return a string representation of the shim.
@@ -85,15 +143,57 @@ func (s Shim) String() string {
 		builder.WriteString(fmt.Sprintf(" Parameters: %s\n", strings.Join(s.Parameters, ",")))
 	}
 
+	if len(s.Platforms) > 0 {
+		builder.WriteString(fmt.Sprintf("  Platforms: %s\n", strings.Join(s.Platforms, ",")))
+	}
+
+	if len(s.Variants) > 0 {
+		builder.WriteString(fmt.Sprintf("   Variants: %s\n", strings.Join(sortedKeys(s.Variants), ",")))
+	}
+
+	if len(s.Tags) > 0 {
+		builder.WriteString(fmt.Sprintf("       Tags: %s\n", strings.Join(s.Tags, ",")))
+	}
+
+	if len(s.Categories) > 0 {
+		builder.WriteString(fmt.Sprintf(" Categories: %s\n", strings.Join(s.Categories, ",")))
+	}
+
+	if s.Image != "" {
+		builder.WriteString(fmt.Sprintf("      Image: %s\n", s.ImageReference()))
+	}
+
+	if s.MinConshimVersion != "" {
+		builder.WriteString(fmt.Sprintf("   Requires: conshim %s or newer\n", s.MinConshimVersion))
+	}
+
+	if s.Upstream != nil {
+		builder.WriteString(fmt.Sprintf("   Upstream: %s\n", s.Upstream))
+	}
+
 	builder.WriteString(fmt.Sprintf("    Command: %s", s.Command))
 
 	return builder.String()
 }
 
+// ImageReference will return the reference used to run the shim's image. If the image has a pinned
+// digest, the reference will be in the form "image@sha256:...".
+func (s Shim) ImageReference() string {
+	if s.Image == "" || s.Digest == "" {
+		return s.Image
+	}
+
+	return s.Image + "@" + s.Digest
+}
+
 // RenderShim will render the shim and replace the parameters with the provided values.
 func (s Shim) RenderShim(parameters map[string]string) (string, error) {
 	renderedShim := &bytes.Buffer{}
 
+	if s.Image != "" {
+		s.Command = strings.ReplaceAll(s.Command, ImagePlaceholder, s.ImageReference())
+	}
+
 	if err := templates[bashTemplate].Execute(renderedShim, s); err != nil {
 		return "", errors.Wrap(err, "error rendering template for add")
 	}
@@ -108,7 +208,20 @@ func (s Shim) RenderShim(parameters map[string]string) (string, error) {
 }
 
 // ParseShimFromReader will parse a shim object from a file. Right now this only supports bash templates.
+// If the shim can't be parsed, the reason is reported in place of the command.
 func ParseShimFromReader(shimFile string, reader io.Reader) Shim {
+	shimInfo, err := ParseShim(shimFile, reader)
+
+	if err != nil {
+		shimInfo.Command = err.Error()
+	}
+
+	return shimInfo
+}
+
+// ParseShim will parse a shim object from a file, returning an error if the file isn't a complete shim.
+// Right now this only supports bash templates.
+func ParseShim(shimFile string, reader io.Reader) (Shim, error) {
 	shimInfo := Shim{
 		Name:    shimFile,
 		Source:  "???",
@@ -119,24 +232,19 @@ func ParseShimFromReader(shimFile string, reader io.Reader) Shim {
 
 	if readErr != nil {
 		zap.S().Debugf("error reading contents of shim '%s': %v", shimFile, readErr)
-		shimInfo.Command = "error reading contents"
 
-		return shimInfo
+		return shimInfo, errors.New(readErrorMessage)
 	}
 
 	scanner := bufio.NewScanner(bytes.NewBuffer(contents))
 
 	// Shims should have three lines: a shebang header, a metadata comment line, and the actual command.
 	if !scanner.Scan() {
-		shimInfo.Command = shebangMissingErrorMessage
-
-		return shimInfo
+		return shimInfo, errors.New(shebangMissingErrorMessage)
 	}
 
 	if !scanner.Scan() {
-		shimInfo.Command = metadataMissingErrorMessage
-
-		return shimInfo
+		return shimInfo, errors.New(metadataMissingErrorMessage)
 	}
 
 	sourceVersion := scanner.Text()
@@ -149,12 +257,32 @@ func ParseShimFromReader(shimFile string, reader io.Reader) Shim {
 	}
 
 	if !scanner.Scan() {
-		shimInfo.Command = commandMissingErrorMessage
-	} else {
-		shimInfo.Command = scanner.Text()
+		return shimInfo, errors.New(commandMissingErrorMessage)
 	}
 
-	return shimInfo
+	shimInfo.Command = scanner.Text()
+
+	return shimInfo, nil
+}
+
+// DetectParameters will return the names of the {{parameter}} placeholders in the command, in the order
+// they first appear. The {{image}} placeholder is not a parameter.
+func DetectParameters(command string) []string {
+	seen := map[string]bool{}
+	parameters := []string{}
+
+	for _, match := range parameterRegex.FindAllStringSubmatch(command, -1) {
+		parameter := match[1]
+
+		if match[0] == ImagePlaceholder || seen[parameter] {
+			continue
+		}
+
+		seen[parameter] = true
+		parameters = append(parameters, parameter)
+	}
+
+	return parameters
 }
 
 // ShimsListToString will take a list of shims and create a string.
@@ -176,6 +304,26 @@ func ShimsListToString(shims []Shim) string {
 			builder.WriteString(fmt.Sprintf(" Parameters: %s\n", strings.Join(shim.Parameters, ",")))
 		}
 
+		if len(shim.Platforms) > 0 {
+			builder.WriteString(fmt.Sprintf("  Platforms: %s\n", strings.Join(shim.Platforms, ",")))
+		}
+
+		if len(shim.Variants) > 0 {
+			builder.WriteString(fmt.Sprintf("   Variants: %s\n", strings.Join(sortedKeys(shim.Variants), ",")))
+		}
+
+		if len(shim.Tags) > 0 {
+			builder.WriteString(fmt.Sprintf("       Tags: %s\n", strings.Join(shim.Tags, ",")))
+		}
+
+		if len(shim.Categories) > 0 {
+			builder.WriteString(fmt.Sprintf(" Categories: %s\n", strings.Join(shim.Categories, ",")))
+		}
+
+		if shim.Image != "" {
+			builder.WriteString(fmt.Sprintf("      Image: %s\n", shim.ImageReference()))
+		}
+
 		builder.WriteString(fmt.Sprintf("    Command: %s\n", shim.Command))
 		entries = append(entries, builder.String())
 	}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -85,3 +86,72 @@ docker run container "$@"`,
 		}()
 	}
 }
+
+func TestRenderShimImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		shim     Shim
+		expected string
+	}{
+		{
+			name:     "no image",
+			shim:     Shim{Command: "docker run {{tag}}"},
+			expected: "docker run latest",
+		},
+		{
+			name:     "unpinned image",
+			shim:     Shim{Command: "docker run {{image}}", Image: "node:20"},
+			expected: "docker run node:20",
+		},
+		{
+			name:     "pinned image",
+			shim:     Shim{Command: "docker run {{image}} {{tag}}", Image: "node:20", Digest: "sha256:1234"},
+			expected: "docker run node:20@sha256:1234 latest",
+		},
+	}
+
+	for _, test := range tests {
+		rendered, err := test.shim.RenderShim(map[string]string{"tag": "latest"})
+		assert.NoError(t, err, "%s: rendering should not error", test.name)
+
+		lines := strings.Split(rendered, "\n")
+		assert.Equal(t, test.expected, lines[len(lines)-1], "%s: commands should match", test.name)
+	}
+}
+
+func TestDetectParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected []string
+	}{
+		{
+			name:     "no parameters",
+			command:  `docker run jq "$@"`,
+			expected: []string{},
+		},
+		{
+			name:     "parameters in order of appearance",
+			command:  `docker run -v {{dir}}:/work -e TOKEN={{token}} {{image}} --dir {{dir}} "$@"`,
+			expected: []string{"dir", "token"},
+		},
+		{
+			name:     "bash expansions are not parameters",
+			command:  `docker run -v "${HOME}:/home" jq {{ spaced }} "$@"`,
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, DetectParameters(test.command), "%s: parameters should match", test.name)
+	}
+}
+
+func TestParseShim(t *testing.T) {
+	_, err := ParseShim("test", strings.NewReader("#!/usr/bin/env bash\n# source: some-source version: 1234567\n"))
+	assert.EqualError(t, err, commandMissingErrorMessage, "incomplete shim should error")
+
+	s, err := ParseShim("test", strings.NewReader("#!/usr/bin/env bash\n# source: some-source version: 1234567\ndocker run jq"))
+	assert.NoError(t, err, "complete shim should not error")
+	assert.Equal(t, "docker run jq", s.Command, "commands should match")
+}
@@ -0,0 +1,108 @@
+package shim
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/meowfaceman/conshim/pkg/semver"
+)
+
+var (
+	parameterNameRegex = regexp.MustCompile(`^[A-Za-z_][\w-]*$`)
+	platformRegex      = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9]+(/[a-z0-9]+)?)?$`)
+)
+
+// ValidateName will check that the name can be used as the file name of a shim in the bin directory. Shim
+// names come from manifests and image labels, so anything that could escape the bin directory or hide the
+// shim is refused.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("shim name must not be empty")
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("shim name '%s' must not contain path separators", name)
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("shim name '%s' must not start with a dot", name)
+	case strings.Contains(name, ".."):
+		return fmt.Errorf("shim name '%s' must not contain '..'", name)
+	default:
+		return nil
+	}
+}
+
+// Validate will check that the shim is well formed, returning every problem that was found.
+func (s Shim) Validate() error {
+	result := &multierror.Error{}
+
+	if strings.TrimSpace(s.Command) == "" {
+		result = multierror.Append(result, fmt.Errorf("command must not be empty"))
+	}
+
+	if strings.Contains(s.Command, "\n") {
+		result = multierror.Append(result, fmt.Errorf("command must be a single line"))
+	}
+
+	declared := map[string]bool{}
+	for _, parameter := range s.Parameters {
+		if !parameterNameRegex.MatchString(parameter) {
+			result = multierror.Append(result, fmt.Errorf("parameter '%s' is not a valid name", parameter))
+		}
+
+		declared[parameter] = true
+	}
+
+	usesImage := false
+	ignoresImage := false
+	commands := append([]string{s.Command}, mapValues(s.Variants)...)
+	for _, command := range commands {
+		for _, parameter := range DetectParameters(command) {
+			if !declared[parameter] {
+				result = multierror.Append(result, fmt.Errorf("command uses parameter '%s' which isn't declared", parameter))
+				declared[parameter] = true
+			}
+		}
+
+		usesImage = usesImage || strings.Contains(command, ImagePlaceholder)
+		ignoresImage = ignoresImage || !strings.Contains(command, ImagePlaceholder)
+	}
+
+	if usesImage && s.Image == "" {
+		result = multierror.Append(result, fmt.Errorf("command uses %s but the shim has no image", ImagePlaceholder))
+	}
+
+	// A command without the placeholder runs whatever image it hard-codes, so the shim's image and any
+	// pinned digest would never reach the rendered shim.
+	if ignoresImage && s.Image != "" {
+		result = multierror.Append(result, fmt.Errorf("shim has an image but its command doesn't use %s", ImagePlaceholder))
+	}
+
+	for _, platform := range append(append([]string{}, s.Platforms...), sortedKeys(s.Variants)...) {
+		if !platformRegex.MatchString(platform) {
+			result = multierror.Append(result, fmt.Errorf("platform '%s' must be in the form os or os/arch", platform))
+		}
+	}
+
+	if s.MinConshimVersion != "" {
+		if _, err := semver.Parse(s.MinConshimVersion); err != nil {
+			result = multierror.Append(result, fmt.Errorf("minimum conshim version: %v", err))
+		}
+	}
+
+	if s.Digest != "" && !strings.HasPrefix(s.Digest, "sha256:") {
+		result = multierror.Append(result, fmt.Errorf("digest '%s' must be in the form sha256:...", s.Digest))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// mapValues will return the values of the map in order of their keys.
+func mapValues(m map[string]string) []string {
+	values := []string{}
+	for _, key := range sortedKeys(m) {
+		values = append(values, m[key])
+	}
+
+	return values
+}
@@ -0,0 +1,102 @@
+package shim
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		shim           Shim
+		expectedErrors int
+	}{
+		{
+			name: "valid shim",
+			shim: Shim{
+				Command:    `docker run -v {{dir}}:/work {{image}} "$@"`,
+				Parameters: []string{"dir"},
+				Image:      "jq:1.6",
+				Digest:     "sha256:aaaa",
+				Platforms:  []string{"linux/amd64", "darwin"},
+				Variants:   map[string]string{"linux/arm64": `docker run {{dir}} {{image}}`},
+			},
+		},
+		{
+			name:           "empty command",
+			shim:           Shim{Command: "  "},
+			expectedErrors: 1,
+		},
+		{
+			name: "undeclared and invalid parameters",
+			shim: Shim{
+				Command:    `docker run {{dir}} {{token}}`,
+				Parameters: []string{"dir", "not valid"},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "image placeholder without image",
+			shim: Shim{
+				Command:  `docker run {{image}}`,
+				Variants: map[string]string{"linux/arm64": `docker run {{image}}`},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "image without placeholder",
+			shim: Shim{
+				Command:  `docker run {{image}}`,
+				Image:    "jq:1.6",
+				Variants: map[string]string{"linux/arm64": `docker run jq:1.6`},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "invalid platforms and digest",
+			shim: Shim{
+				Command:   `docker run jq`,
+				Platforms: []string{"Linux AMD64"},
+				Variants:  map[string]string{"linux/": "docker run jq"},
+				Digest:    "aaaa",
+			},
+			expectedErrors: 3,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.shim.Validate()
+
+		if test.expectedErrors == 0 {
+			assert.NoError(t, err, "%s: should not error", test.name)
+			continue
+		}
+
+		if assert.Error(t, err, "%s: should error", test.name) {
+			assert.Len(t, err.(*multierror.Error).Errors, test.expectedErrors, "%s: number of errors should match", test.name)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectedErr bool
+	}{
+		{name: "jq"},
+		{name: "docker-compose"},
+		{name: "python3.9"},
+		{name: "", expectedErr: true},
+		{name: "../../.bashrc", expectedErr: true},
+		{name: "bin/jq", expectedErr: true},
+		{name: `bin\jq`, expectedErr: true},
+		{name: ".hidden", expectedErr: true},
+		{name: "jq..", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedErr, ValidateName(test.name) != nil, "%s: error states should equal", test.name)
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic will write a file by writing to a temporary file in the same directory and renaming it
+// over the destination once it's complete, so that readers never see a partially written file.
+func WriteFileAtomic(path string, perm os.FileMode, write func(io.Writer) error) (err error) {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+
+	if err != nil {
+		return errors.Wrapf(err, "error creating temporary file for '%s'", path)
+	}
+
+	tmpFileName := tmpFile.Name()
+
+	defer func() {
+		if err != nil {
+			_ = tmpFile.Close()
+			_ = os.Remove(tmpFileName)
+		}
+	}()
+
+	if err = write(tmpFile); err != nil {
+		return err
+	}
+
+	if err = tmpFile.Sync(); err != nil {
+		return errors.Wrapf(err, "error syncing temporary file for '%s'", path)
+	}
+
+	if err = tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "error closing temporary file for '%s'", path)
+	}
+
+	if err = os.Chmod(tmpFileName, perm); err != nil {
+		return errors.Wrapf(err, "error setting permissions of temporary file for '%s'", path)
+	}
+
+	if err = os.Rename(tmpFileName, path); err != nil {
+		return errors.Wrapf(err, "error replacing '%s'", path)
+	}
+
+	return nil
+}
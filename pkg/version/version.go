@@ -0,0 +1,29 @@
+package version
+
+import (
+	"github.com/meowfaceman/conshim/pkg/semver"
+)
+
+const (
+	// Development is the version of builds that weren't given a version at link time.
+	Development = "dev"
+)
+
+var (
+	// Version is the version of conshim. It's set at link time with:
+	//
+	//   -ldflags "-X github.com/meowfaceman/conshim/pkg/version.Version=1.2.3"
+	Version = Development
+)
+
+// Client will return the parsed version of conshim. The boolean will be false for development builds
+// and builds with a version that isn't a semantic version, which are treated as new enough for anything.
+func Client() (semver.Version, bool) {
+	v, err := semver.Parse(Version)
+
+	if err != nil {
+		return semver.Version{}, false
+	}
+
+	return v, true
+}